@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/SoyebSarkar/Hiberstack/internal/lifecycle"
+	"github.com/SoyebSarkar/Hiberstack/internal/policy"
+	"github.com/SoyebSarkar/Hiberstack/internal/prewarm"
+	"github.com/SoyebSarkar/Hiberstack/internal/reconciler"
 	"github.com/SoyebSarkar/Hiberstack/internal/state"
 )
 
@@ -12,6 +20,9 @@ func registerAdmin(
 	mux *http.ServeMux,
 	lifecycleMgr *lifecycle.Manager,
 	stateStore *state.Store,
+	reconcile *reconciler.Reconciler,
+	policies *policy.Set,
+	planner *prewarm.Planner,
 ) {
 	mux.HandleFunc("/admin/reload/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -24,8 +35,9 @@ func registerAdmin(
 			http.Error(w, "collection name required", http.StatusBadRequest)
 			return
 		}
-		if err := lifecycleMgr.Reload(collection); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		// A client hanging up must not abort the reload half-way
+		if _, err := lifecycleMgr.Reload(context.WithoutCancel(r.Context()), collection); err != nil {
+			http.Error(w, err.Error(), lifecycleStatus(err))
 			return
 		}
 
@@ -42,11 +54,257 @@ func registerAdmin(
 			http.Error(w, "collection name required", http.StatusBadRequest)
 			return
 		}
-		stateStore.Set(collection, state.Draining)
-		if err := lifecycleMgr.Offload(collection); err != nil {
+		if pinned, _ := stateStore.Pinned(collection); pinned {
+			http.Error(w, "collection is pinned", http.StatusConflict)
+			return
+		}
+		if eff := policies.Resolve(collection); !eff.Offloadable() {
+			http.Error(w, fmt.Sprintf("policy %s does not allow offloading the collection", eff.Policy), http.StatusConflict)
+			return
+		}
+		// Collections are first seen on offload; start tracking them as HOT
+		if _, err := stateStore.Register(collection, state.Hot); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if err := stateStore.Transition(collection, state.Hot, state.Draining); err != nil {
+			http.Error(w, err.Error(), lifecycleStatus(err))
+			return
+		}
+		if err := lifecycleMgr.Offload(context.WithoutCancel(r.Context()), collection); err != nil {
+			http.Error(w, err.Error(), lifecycleStatus(err))
+			return
+		}
 		w.Write([]byte("collection offloaded\n"))
 	})
+	mux.HandleFunc("/admin/retry/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		collection := strings.TrimPrefix(r.URL.Path, "/admin/retry/")
+		if collection == "" {
+			http.Error(w, "collection name required", http.StatusBadRequest)
+			return
+		}
+		if err := lifecycleMgr.Retry(context.WithoutCancel(r.Context()), collection); err != nil {
+			http.Error(w, err.Error(), lifecycleStatus(err))
+			return
+		}
+		w.Write([]byte("collection recovered\n"))
+	})
+	mux.HandleFunc("/admin/reset/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		collection := strings.TrimPrefix(r.URL.Path, "/admin/reset/")
+		if collection == "" {
+			http.Error(w, "collection name required", http.StatusBadRequest)
+			return
+		}
+		st, err := lifecycleMgr.Reset(r.Context(), collection)
+		if err != nil {
+			http.Error(w, err.Error(), lifecycleStatus(err))
+			return
+		}
+		fmt.Fprintf(w, "collection reset to %s\n", st)
+	})
+	mux.HandleFunc("/admin/pin/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		collection := strings.TrimPrefix(r.URL.Path, "/admin/pin/")
+		if collection == "" {
+			http.Error(w, "collection name required", http.StatusBadRequest)
+			return
+		}
+		until, err := pinExpiry(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := stateStore.Pin(collection, until); err != nil {
+			http.Error(w, err.Error(), lifecycleStatus(err))
+			return
+		}
+
+		// A pinned collection is expected to be loaded
+		if stateStore.Get(collection) == state.Cold {
+			if _, err := lifecycleMgr.Reload(context.WithoutCancel(r.Context()), collection); err != nil {
+				http.Error(w, "collection pinned, reload failed: "+err.Error(), lifecycleStatus(err))
+				return
+			}
+		}
+
+		if until.IsZero() {
+			w.Write([]byte("collection pinned\n"))
+			return
+		}
+		fmt.Fprintf(w, "collection pinned until %s\n", until.UTC().Format(time.RFC3339))
+	})
+	mux.HandleFunc("/admin/unpin/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		collection := strings.TrimPrefix(r.URL.Path, "/admin/unpin/")
+		if collection == "" {
+			http.Error(w, "collection name required", http.StatusBadRequest)
+			return
+		}
+		if err := stateStore.Unpin(collection); err != nil {
+			http.Error(w, err.Error(), lifecycleStatus(err))
+			return
+		}
+		w.Write([]byte("collection unpinned\n"))
+	})
+	mux.HandleFunc("/admin/status/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		collection := strings.TrimPrefix(r.URL.Path, "/admin/status/")
+		if collection == "" {
+			http.Error(w, "collection name required", http.StatusBadRequest)
+			return
+		}
+		if !stateStore.Exists(collection) {
+			http.Error(w, "unknown collection", http.StatusNotFound)
+			return
+		}
+
+		status := struct {
+			Collection     string         `json:"collection"`
+			State          state.State    `json:"state"`
+			Pinned         bool           `json:"pinned"`
+			PinnedUntil    *time.Time     `json:"pinned_until,omitempty"`
+			ProtectedUntil *time.Time     `json:"protected_until,omitempty"`
+			Instance       string         `json:"instance,omitempty"`
+			Failure        *state.Failure `json:"failure,omitempty"`
+		}{
+			Collection: collection,
+			State:      stateStore.Get(collection),
+			Instance:   stateStore.Placement(collection),
+			Failure:    stateStore.GetFailure(collection),
+		}
+		pinned, until := stateStore.Pinned(collection)
+		status.Pinned = pinned
+		if pinned && !until.IsZero() {
+			status.PinnedUntil = &until
+		}
+		if until := stateStore.ProtectedUntil(collection); !until.IsZero() {
+			status.ProtectedUntil = &until
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	})
+	mux.HandleFunc("/admin/policy/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		collection := strings.TrimPrefix(r.URL.Path, "/admin/policy/")
+		if collection == "" {
+			http.Error(w, "collection name required", http.StatusBadRequest)
+			return
+		}
+
+		eff := policies.Resolve(collection)
+		out := struct {
+			policy.Effective
+			NextPrewarm *time.Time `json:"next_prewarm,omitempty"`
+		}{Effective: eff}
+		now := time.Now()
+		for _, pw := range eff.Prewarm {
+			if next := pw.Next(now); !next.IsZero() && (out.NextPrewarm == nil || next.Before(*out.NextPrewarm)) {
+				out.NextPrewarm = &next
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	})
+	mux.HandleFunc("/admin/predict/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		collection := strings.TrimPrefix(r.URL.Path, "/admin/predict/")
+		if collection == "" {
+			http.Error(w, "collection name required", http.StatusBadRequest)
+			return
+		}
+		if !stateStore.Exists(collection) {
+			http.Error(w, "unknown collection", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(planner.Predict(collection, time.Now()))
+	})
+	mux.HandleFunc("/admin/drift", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		drift := reconcile.Drift()
+		if drift == nil {
+			drift = []reconciler.Drift{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(drift)
+	})
+}
+
+// lifecycleStatus maps lifecycle errors to a status code; refusals due to the
+// collection's current state are conflicts.
+func lifecycleStatus(err error) int {
+	var te *state.TransitionError
+	if errors.Is(err, lifecycle.ErrNotFailed) || errors.As(err, &te) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, state.ErrUnknownCollection) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, lifecycle.ErrShuttingDown) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// pinExpiry reads the optional pin expiry from ?until=<RFC 3339 time> or
+// ?for=<duration>. No parameter means the pin never expires.
+func pinExpiry(r *http.Request) (time.Time, error) {
+	q := r.URL.Query()
+	switch {
+	case q.Get("until") != "" && q.Get("for") != "":
+		return time.Time{}, errors.New("use either until or for, not both")
+	case q.Get("until") != "":
+		t, err := time.Parse(time.RFC3339, q.Get("until"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid until: %w", err)
+		}
+		if !t.After(time.Now()) {
+			return time.Time{}, errors.New("until is in the past")
+		}
+		return t, nil
+	case q.Get("for") != "":
+		d, err := time.ParseDuration(q.Get("for"))
+		if err != nil || d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid for: %q", q.Get("for"))
+		}
+		return time.Now().Add(d), nil
+	}
+	return time.Time{}, nil
 }
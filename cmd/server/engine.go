@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/config"
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+	"github.com/SoyebSarkar/Hiberstack/internal/engine/meilisearch"
+	"github.com/SoyebSarkar/Hiberstack/internal/engine/opensearch"
+	"github.com/SoyebSarkar/Hiberstack/internal/engine/typesense"
+	"github.com/SoyebSarkar/Hiberstack/internal/proxy"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+)
+
+// newEngine builds the configured engine adapter and returns it together
+// with the upstream the proxy should forward to. For Typesense the node
+// health checks run until ctx is done, and a pool of instances records
+// collection placements in stateStore.
+func newEngine(ctx context.Context, cfg *config.Config, stateStore *state.Store) (engine.Engine, proxy.Upstream, error) {
+	switch cfg.Engine {
+	case config.EngineMeilisearch:
+		upstream, err := proxy.NewSingleHost(cfg.MeilisearchURL)
+		return meilisearch.New(cfg.MeilisearchURL, cfg.MeilisearchAPIKey), upstream, err
+	case config.EngineOpenSearch, config.EngineElasticsearch:
+		upstream, err := proxy.NewSingleHost(cfg.OpenSearchURL)
+		return opensearch.New(cfg.OpenSearchURL, cfg.OpenSearchUsername, cfg.OpenSearchPassword), upstream, err
+	default:
+		if len(cfg.TypesenseInstances) > 0 {
+			return newTypesensePool(ctx, cfg, stateStore)
+		}
+		client, err := newTypesense(cfg, cfg.TypesenseClusterNodes())
+		if err != nil {
+			return nil, nil, err
+		}
+		client.Cluster.Start(ctx, cfg.TypesenseHealthInterval)
+		return client, client.Cluster, nil
+	}
+}
+
+func newTypesensePool(ctx context.Context, cfg *config.Config, stateStore *state.Store) (*typesense.Pool, *typesense.Pool, error) {
+	var instances []*typesense.Client
+	for _, instance := range cfg.TypesenseInstances {
+		client, err := newTypesense(cfg, []string{instance})
+		if err != nil {
+			return nil, nil, err
+		}
+		instances = append(instances, client)
+	}
+
+	pool, err := typesense.NewPool(instances, stateStore)
+	if err != nil {
+		return nil, nil, err
+	}
+	pool.Start(ctx, cfg.TypesenseHealthInterval)
+	return pool, pool, nil
+}
+
+func newTypesense(cfg *config.Config, nodes []string) (*typesense.Client, error) {
+	client, err := typesense.New(nodes, cfg.TypesenseAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	client.Timeouts = cfg.TypesenseTimeouts
+	client.Retry = cfg.TypesenseRetry
+	return client, nil
+}
@@ -1,53 +1,159 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/SoyebSarkar/Hiberstack/internal/config"
-	"github.com/SoyebSarkar/Hiberstack/internal/engine/typesense"
 	"github.com/SoyebSarkar/Hiberstack/internal/lifecycle"
+	"github.com/SoyebSarkar/Hiberstack/internal/prewarm"
 	"github.com/SoyebSarkar/Hiberstack/internal/proxy"
+	"github.com/SoyebSarkar/Hiberstack/internal/reconciler"
 	"github.com/SoyebSarkar/Hiberstack/internal/scheduler"
 	"github.com/SoyebSarkar/Hiberstack/internal/state"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
-	cfg := config.Load()
+	if len(os.Args) > 1 && os.Args[1] == "validate-config" {
+		os.Exit(validateConfig(os.Args[2:]))
+	}
+
+	flags := flag.NewFlagSet("hiberstack", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to a YAML config file; environment variables override it")
+	flags.Parse(os.Args[1:])
+
+	cfg := config.MustLoad(*configPath)
 
-	// Initialize Typesense client
-	ts := typesense.New(cfg.TypesenseURL, cfg.TypesenseAPIKey)
+	// SIGTERM or Ctrl-C cancels ctx, which stops every background loop
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
 	// Initialize state store
 	stateStore, err := state.NewSQLite(cfg.StateDBPath)
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	// Initialize search engine adapter
+	eng, upstream, err := newEngine(ctx, cfg, stateStore)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize snapshot store and discard half-written snapshots left
+	// by a previous crash
+	snapshots, err := newSnapshotStore(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := snapshots.Recover(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize lifecycle manager
 	lifecycleMgr := lifecycle.New(
-		ts,
-		cfg.SnapshotDir,
+		eng,
+		snapshots,
+		cfg.SnapshotCompression,
 		stateStore,
 		cfg.MaxConcurrentReloads,
+		cfg.ImportFailureThreshold,
 	)
 
+	// Resolve reloads and offloads interrupted by a previous crash
+	if err := lifecycleMgr.RecoverInterrupted(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	// Load per-collection policies; the global settings are the fallback
+	policies, err := cfg.PolicySet()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Memory-pressure eviction needs an engine that reports memory use
+	var memory *scheduler.MemoryPressure
+	if cfg.EvictMemory() {
+		reporter, ok := eng.(scheduler.MemoryEngine)
+		if !ok {
+			log.Fatalf("EVICTION=memory is not supported by engine %s", cfg.Engine)
+		}
+		memory = &scheduler.MemoryPressure{
+			Engine:    reporter,
+			HighWater: cfg.MemoryHighWater,
+			LowWater:  cfg.MemoryLowWater,
+			Budget:    cfg.MemoryBudgetBytes,
+			Interval:  cfg.MemoryCheckInterval,
+		}
+	}
+
 	// Initialize and start scheduler
 	scheduler := scheduler.New(
 		stateStore,
 		lifecycleMgr,
-		cfg.OffloadAfter,
-		cfg.DrainGracePeriod,
+		policies,
 		cfg.SchedulerInterval,
+		cfg.EvictIdle(),
+		memory,
+	)
+	scheduler.Start(ctx)
+
+	// Reload collections ahead of their prewarm schedules
+	prewarm.New(stateStore, lifecycleMgr, policies).Start(ctx)
+
+	// Learn access patterns and reload ahead of predicted traffic
+	planner := prewarm.NewPlanner(
+		stateStore,
+		lifecycleMgr,
+		policies,
+		cfg.PredictThreshold,
+		cfg.PredictLead,
+		cfg.PredictHistoryWeeks,
+		cfg.PredictMinWeeks,
 	)
-	scheduler.Start()
+	if cfg.PredictivePrewarm {
+		planner.Start(ctx)
+	}
+
+	// Keep state.db in line with collections created or deleted outside
+	// Hiberstack
+	reconciler := reconciler.New(stateStore, eng, snapshots, cfg.ReconcileInterval)
+	reconciler.Start(ctx)
 
 	// Initialize proxy
-	proxy, err := proxy.New(cfg.TypesenseURL, lifecycleMgr, stateStore, cfg.ReloadMode)
+	proxy, err := proxy.New(upstream, eng, lifecycleMgr, stateStore, policies)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Pick up configuration changes without a restart, so running drains
+	// and in-flight reloads are not lost
+	config.Watch(ctx, *configPath, cfg, func(next *config.Config) {
+		nextPolicies, err := next.PolicySet()
+		if err != nil {
+			log.Printf("config reload: policies not applied: %v", err)
+		} else {
+			policies.Update(nextPolicies)
+		}
+		lifecycleMgr.SetMaxConcurrentReloads(next.MaxConcurrentReloads)
+		scheduler.SetInterval(next.SchedulerInterval)
+		scheduler.SetIdle(next.EvictIdle())
+		scheduler.SetMemoryPressure(
+			next.MemoryHighWater,
+			next.MemoryLowWater,
+			next.MemoryBudgetBytes,
+			next.MemoryCheckInterval,
+		)
+	})
+
 	// Setup HTTP server with admin routes and proxy fallback
 	mux := http.NewServeMux()
 
@@ -55,15 +161,52 @@ func main() {
 	mux.Handle("/metrics", promhttp.Handler())
 
 	// 1️⃣ Register admin routes FIRST
-	registerAdmin(mux, lifecycleMgr, stateStore)
+	registerAdmin(mux, lifecycleMgr, stateStore, reconciler, policies, planner)
 
 	// 2️⃣ Attach proxy as fallback
 	mux.Handle("/", proxy)
 	handler := loggingMiddleware(mux)
 
 	// 3️⃣ Start server with mux
-	http.ListenAndServe(":"+cfg.Port, handler)
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: handler}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	// A second signal kills the process right away
+	stop()
+	shutdown(server, lifecycleMgr, cfg.ShutdownTimeout)
+}
 
+// shutdown runs once the background loops have stopped with the signal
+// context. It refuses new reloads and offloads first, so neither a request
+// still being drained nor a late scheduler or prewarm goroutine can start
+// one, then waits for in-flight requests and finally for running reloads
+// and offloads. HTTP draining gets at most half of the timeout so the
+// lifecycle phase always keeps its share; whatever is still running at
+// the deadline is cancelled and rolled back.
+func shutdown(server *http.Server, lifecycleMgr *lifecycle.Manager, timeout time.Duration) {
+	log.Printf("shutdown started timeout=%s", timeout)
+	deadline := time.Now().Add(timeout)
+
+	lifecycleMgr.Close()
+
+	httpCtx, cancel := context.WithTimeout(context.Background(), timeout/2)
+	defer cancel()
+	if err := server.Shutdown(httpCtx); err != nil {
+		log.Printf("shutdown http server: %v", err)
+		server.Close()
+	}
+
+	lifecycleCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := lifecycleMgr.Shutdown(lifecycleCtx); err != nil {
+		log.Printf("shutdown lifecycle: %v", err)
+	}
+	log.Printf("shutdown complete")
 }
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/SoyebSarkar/Hiberstack/internal/config"
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
+)
+
+func newSnapshotStore(cfg *config.Config) (snapshot.Store, error) {
+	switch cfg.SnapshotStore {
+	case config.SnapshotStoreS3:
+		return snapshot.NewS3(cfg.S3)
+	default:
+		return snapshot.NewLocal(cfg.SnapshotDir), nil
+	}
+}
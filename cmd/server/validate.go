@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/config"
+)
+
+// validateConfig implements `hiberstack validate-config`: it loads the
+// configuration exactly like the server would and prints every problem.
+func validateConfig(args []string) int {
+	flags := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	configPath := flags.String("config", "", "path to a YAML config file; environment variables override it")
+	flags.Parse(args)
+
+	_, err := config.Load(*configPath)
+	if err == nil {
+		fmt.Println("configuration is valid")
+		return 0
+	}
+
+	var joined interface{ Unwrap() []error }
+	errs := []error{err}
+	if errors.As(err, &joined) {
+		errs = joined.Unwrap()
+	}
+
+	fmt.Fprintf(os.Stderr, "configuration has %d error(s):\n", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "  - %v\n", e)
+	}
+	return 1
+}
@@ -1,10 +1,16 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
-	"os"
-	"strconv"
+	"net/url"
+	"slices"
 	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine/typesense"
+	"github.com/SoyebSarkar/Hiberstack/internal/policy"
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
 )
 
 type ReloadMode string
@@ -14,92 +20,333 @@ const (
 	ReloadBlocking ReloadMode = "blocking" // future
 )
 
+const (
+	ModeProxy    = "proxy"
+	ModeObserver = "observer" // future
+)
+
+const (
+	SnapshotStoreLocal = "local"
+	SnapshotStoreS3    = "s3"
+)
+
+// Eviction strategies; any combination can be enabled through EVICTION.
+const (
+	EvictIdle   = "idle"
+	EvictMemory = "memory"
+)
+
+const (
+	EngineTypesense     = "typesense"
+	EngineMeilisearch   = "meilisearch"
+	EngineOpenSearch    = "opensearch"
+	EngineElasticsearch = "elasticsearch"
+)
+
 type Config struct {
-	TypesenseURL         string
-	TypesenseAPIKey      string
-	Port                 string
-	OffloadAfter         time.Duration
-	DrainGracePeriod     time.Duration
-	SchedulerInterval    time.Duration
-	ReloadMode           ReloadMode
-	MaxConcurrentReloads int
-	SnapshotDir          string
-	StateDBPath          string
-	ListenAddr           string
+	Engine          string
+	Mode            string
+	TypesenseURL    string
+	TypesenseAPIKey string
+	// TypesenseNodes lists every node of a Typesense cluster; when empty,
+	// TypesenseURL is the only node
+	TypesenseNodes []string
+	// TypesenseInstances lists independent Typesense servers to spread
+	// collections over, each a single node
+	TypesenseInstances      []string
+	TypesenseHealthInterval time.Duration
+	TypesenseTimeouts       typesense.Timeouts
+	TypesenseRetry          typesense.Retry
+	MeilisearchURL          string
+	MeilisearchAPIKey       string
+	OpenSearchURL           string
+	OpenSearchUsername      string
+	OpenSearchPassword      string
+	Port                    string
+	OffloadAfter            time.Duration
+	DrainGracePeriod        time.Duration
+	SchedulerInterval       time.Duration
+	ReconcileInterval       time.Duration
+	ShutdownTimeout         time.Duration
+	ReloadMode              ReloadMode
+	PolicyFile              string
+	Policies                []policy.Policy
+	PrewarmProtect          time.Duration
+	PredictivePrewarm       bool
+	PredictThreshold        float64
+	PredictLead             time.Duration
+	PredictHistoryWeeks     int
+	PredictMinWeeks         int
+	Eviction                []string
+	MemoryHighWater         float64
+	MemoryLowWater          float64
+	MemoryBudgetBytes       int64
+	MemoryCheckInterval     time.Duration
+	MaxConcurrentReloads    int
+	ImportFailureThreshold  float64
+	SnapshotDir             string
+	SnapshotCompression     snapshot.Codec
+	SnapshotStore           string
+	S3                      snapshot.S3Config
+	StateDBPath             string
+	ListenAddr              string
 }
 
-func Load() *Config {
-	cfg := &Config{
-		TypesenseURL:         getEnv("TYPESENSE_URL", "http://localhost:8108"),
-		Port:                 getEnv("PORT", "8080"),
-		TypesenseAPIKey:      getEnv("TYPESENSE_API_KEY", "xyz"),
-		OffloadAfter:         getDuration("OFFLOAD_AFTER", 6*time.Hour),
-		DrainGracePeriod:     getDuration("DRAIN_GRACE_PERIOD", 30*time.Second),
-		SchedulerInterval:    getDuration("SCHEDULER_INTERVAL", 10*time.Minute),
-		ReloadMode:           ReloadAsync,
-		MaxConcurrentReloads: getInt("MAX_CONCURRENT_RELOADS", 2),
-		SnapshotDir:          getEnv("SNAPSHOT_DIR", "./snapshots"),
-		StateDBPath:          getEnv("STATE_DB_PATH", "./state.db"),
-		ListenAddr:           getEnv("LISTEN_ADDR", "localhost"),
-	}
-	if v := os.Getenv("RELOAD_MODE"); v != "" {
-		switch ReloadMode(v) {
-		case ReloadAsync, ReloadBlocking:
-			cfg.ReloadMode = ReloadMode(v)
-		default:
-			log.Fatalf("invalid RELOAD_MODE: %s", v)
-		}
+// Default returns the configuration used when nothing is set.
+func Default() *Config {
+	return &Config{
+		Engine:                  EngineTypesense,
+		Mode:                    ModeProxy,
+		TypesenseURL:            "http://localhost:8108",
+		TypesenseAPIKey:         "xyz",
+		TypesenseHealthInterval: 5 * time.Second,
+		TypesenseTimeouts:       typesense.DefaultTimeouts,
+		TypesenseRetry:          typesense.DefaultRetry,
+		MeilisearchURL:          "http://localhost:7700",
+		OpenSearchURL:           "http://localhost:9200",
+		Port:                    "8080",
+		OffloadAfter:            6 * time.Hour,
+		DrainGracePeriod:        30 * time.Second,
+		SchedulerInterval:       10 * time.Minute,
+		ReconcileInterval:       time.Minute,
+		ShutdownTimeout:         30 * time.Second,
+		ReloadMode:              ReloadAsync,
+		PrewarmProtect:          time.Hour,
+		PredictThreshold:        0.5,
+		PredictLead:             10 * time.Minute,
+		PredictHistoryWeeks:     4,
+		PredictMinWeeks:         2,
+		Eviction:                []string{EvictIdle},
+		MemoryHighWater:         0.85,
+		MemoryLowWater:          0.75,
+		MemoryCheckInterval:     30 * time.Second,
+		MaxConcurrentReloads:    2,
+		ImportFailureThreshold:  0,
+		SnapshotDir:             "./snapshots",
+		SnapshotCompression:     snapshot.CodecGzip,
+		SnapshotStore:           SnapshotStoreLocal,
+		S3: snapshot.S3Config{
+			Endpoint: "https://s3.amazonaws.com",
+			Region:   "us-east-1",
+			PartSize: 16 << 20,
+			Timeout:  snapshot.DefaultS3Timeout,
+		},
+		StateDBPath: "./state.db",
+		ListenAddr:  "localhost",
 	}
+}
 
+// Load builds the configuration from the defaults, the YAML file at path
+// (if any) and then environment variables, which take precedence. It
+// returns every problem found, joined, rather than stopping at the first.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	var errs []error
+
+	if path != "" {
+		errs = append(errs, loadFile(path, cfg)...)
+	}
+	errs = append(errs, applyEnv(cfg)...)
+	errs = append(errs, cfg.validate()...)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// MustLoad is Load for the server: any error is fatal.
+func MustLoad(path string) *Config {
+	cfg, err := Load(path)
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
 	logConfig(cfg)
 	return cfg
 }
 
-// Helper functions to read env vars with defaults
-func getEnv(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+func (c *Config) validate() []error {
+	var errs []error
+
+	switch c.Engine {
+	case EngineTypesense, EngineMeilisearch, EngineOpenSearch, EngineElasticsearch:
+	default:
+		errs = append(errs, fmt.Errorf("invalid engine: %q", c.Engine))
 	}
-	return def
-}
 
-func mustEnv(key string) string {
-	v := os.Getenv(key)
-	if v == "" {
-		log.Fatalf("missing required env var: %s", key)
+	if c.Engine == EngineTypesense {
+		for _, node := range c.TypesenseClusterNodes() {
+			if u, err := url.Parse(node); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("invalid typesense node URL: %q", node))
+			}
+		}
+
+		// Placements are recorded by host, so each instance needs its own
+		hosts := make(map[string]bool)
+		for _, instance := range c.TypesenseInstances {
+			u, err := url.Parse(instance)
+			switch {
+			case err != nil || u.Scheme == "" || u.Host == "":
+				errs = append(errs, fmt.Errorf("invalid typesense instance URL: %q", instance))
+			case hosts[u.Host]:
+				errs = append(errs, fmt.Errorf("duplicate typesense instance: %q", instance))
+			}
+			if u != nil {
+				hosts[u.Host] = true
+			}
+		}
+		if len(c.TypesenseInstances) > 0 && len(c.TypesenseNodes) > 0 {
+			errs = append(errs, errors.New("typesense nodes and instances cannot be combined"))
+		}
+	}
+
+	switch c.Mode {
+	case ModeProxy:
+	case ModeObserver:
+		errs = append(errs, errors.New("mode observer is not implemented yet"))
+	default:
+		errs = append(errs, fmt.Errorf("invalid mode: %q", c.Mode))
+	}
+
+	switch c.ReloadMode {
+	case ReloadAsync, ReloadBlocking:
+	default:
+		errs = append(errs, fmt.Errorf("invalid reload mode: %q", c.ReloadMode))
 	}
-	return v
-}
 
-func getDuration(key string, def time.Duration) time.Duration {
-	if v := os.Getenv(key); v != "" {
-		d, err := time.ParseDuration(v)
-		if err != nil {
-			log.Fatalf("invalid duration for %s", key)
+	if _, err := snapshot.ParseCodec(string(c.SnapshotCompression)); err != nil {
+		errs = append(errs, fmt.Errorf("invalid snapshot compression: %q", c.SnapshotCompression))
+	}
+
+	switch c.SnapshotStore {
+	case SnapshotStoreLocal:
+	case SnapshotStoreS3:
+		if c.S3.Bucket == "" {
+			errs = append(errs, errors.New("storage type s3 requires a bucket (S3_BUCKET)"))
 		}
-		return d
+	default:
+		errs = append(errs, fmt.Errorf("invalid snapshot store: %q", c.SnapshotStore))
+	}
+
+	for name, d := range map[string]time.Duration{
+		"offload after":             c.OffloadAfter,
+		"scheduler interval":        c.SchedulerInterval,
+		"reconcile interval":        c.ReconcileInterval,
+		"shutdown timeout":          c.ShutdownTimeout,
+		"memory check interval":     c.MemoryCheckInterval,
+		"typesense health interval": c.TypesenseHealthInterval,
+	} {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", name, d))
+		}
+	}
+
+	for name, d := range map[string]time.Duration{
+		"typesense request timeout":  c.TypesenseTimeouts.Request,
+		"typesense export timeout":   c.TypesenseTimeouts.Export,
+		"typesense import timeout":   c.TypesenseTimeouts.Import,
+		"typesense retry base delay": c.TypesenseRetry.BaseDelay,
+		"typesense retry max delay":  c.TypesenseRetry.MaxDelay,
+		"s3 timeout":                 c.S3.Timeout,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", name, d))
+		}
+	}
+	if c.TypesenseRetry.Attempts < 1 {
+		errs = append(errs, fmt.Errorf("typesense retry attempts must be at least 1, got %d", c.TypesenseRetry.Attempts))
+	}
+
+	if c.MaxConcurrentReloads < 1 {
+		errs = append(errs, fmt.Errorf("max concurrent reloads must be at least 1, got %d", c.MaxConcurrentReloads))
+	}
+
+	if c.ImportFailureThreshold < 0 || c.ImportFailureThreshold > 1 {
+		errs = append(errs, fmt.Errorf("invalid import failure threshold: %v", c.ImportFailureThreshold))
+	}
+
+	if c.PredictThreshold <= 0 || c.PredictThreshold > 1 {
+		errs = append(errs, fmt.Errorf("invalid predict threshold: %v", c.PredictThreshold))
+	}
+	if c.PredictHistoryWeeks < 1 || c.PredictMinWeeks < 1 || c.PredictMinWeeks > c.PredictHistoryWeeks {
+		errs = append(errs, errors.New("invalid prediction history: need 1 <= min weeks <= history weeks"))
+	}
+
+	for _, e := range c.Eviction {
+		switch e {
+		case EvictIdle, EvictMemory:
+		default:
+			errs = append(errs, fmt.Errorf("invalid eviction strategy: %q", e))
+		}
+	}
+	if c.EvictMemory() && !(c.MemoryLowWater > 0 && c.MemoryLowWater < c.MemoryHighWater && c.MemoryHighWater <= 1) {
+		errs = append(errs, errors.New("invalid memory water marks: need 0 < low water < high water <= 1"))
 	}
-	return def
-}
 
-func getInt(key string, def int) int {
-	if v := os.Getenv(key); v != "" {
-		i, err := strconv.Atoi(v)
-		if err != nil {
-			log.Fatalf("invalid int for %s", key)
+	if c.PolicyFile != "" && len(c.Policies) > 0 {
+		errs = append(errs, errors.New("policies are set both inline and through a policy file"))
+	} else if _, err := c.PolicySet(); err != nil {
+		// Report each invalid policy on its own
+		var joined interface{ Unwrap() []error }
+		if errors.As(err, &joined) {
+			for _, e := range joined.Unwrap() {
+				errs = append(errs, fmt.Errorf("policies: %w", e))
+			}
+		} else {
+			errs = append(errs, fmt.Errorf("policies: %w", err))
 		}
-		return i
 	}
-	return def
+
+	return errs
 }
+
+// TypesenseClusterNodes returns the Typesense nodes to use.
+func (c *Config) TypesenseClusterNodes() []string {
+	if len(c.TypesenseNodes) > 0 {
+		return c.TypesenseNodes
+	}
+	return []string{c.TypesenseURL}
+}
+
+func (c *Config) EvictIdle() bool {
+	return slices.Contains(c.Eviction, EvictIdle)
+}
+
+func (c *Config) EvictMemory() bool {
+	return slices.Contains(c.Eviction, EvictMemory)
+}
+
+// PolicySet compiles the per-collection policies, from the policy file if
+// one is configured, with the global settings as defaults.
+func (c *Config) PolicySet() (*policy.Set, error) {
+	defaults := policy.Defaults{
+		OffloadAfter:     c.OffloadAfter,
+		DrainGracePeriod: c.DrainGracePeriod,
+		ReloadMode:       string(c.ReloadMode),
+		PrewarmProtect:   c.PrewarmProtect,
+	}
+	if c.PolicyFile != "" {
+		return policy.Load(c.PolicyFile, defaults)
+	}
+	return policy.New(c.Policies, defaults)
+}
+
 func logConfig(cfg *Config) {
 	log.Printf(
-		"config offload_after=%s drain_grace=%s scheduler_interval=%s reload_mode=%s max_concurrent_reloads=%d",
+		"config engine=%s offload_after=%s drain_grace=%s scheduler_interval=%s reconcile_interval=%s reload_mode=%s policy_file=%q policies=%d eviction=%v max_concurrent_reloads=%d import_failure_threshold=%v snapshot_compression=%s snapshot_store=%s",
+		cfg.Engine,
 		cfg.OffloadAfter,
 		cfg.DrainGracePeriod,
 		cfg.SchedulerInterval,
+		cfg.ReconcileInterval,
 		cfg.ReloadMode,
+		cfg.PolicyFile,
+		len(cfg.Policies),
+		cfg.Eviction,
 		cfg.MaxConcurrentReloads,
+		cfg.ImportFailureThreshold,
+		cfg.SnapshotCompression,
+		cfg.SnapshotStore,
 	)
 
 }
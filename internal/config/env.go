@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
+)
+
+// applyEnv overrides cfg with every environment variable that is set.
+func applyEnv(cfg *Config) []error {
+	var e env
+
+	cfg.Engine = e.str("ENGINE", cfg.Engine)
+	cfg.Mode = e.str("MODE", cfg.Mode)
+	cfg.TypesenseURL = e.str("TYPESENSE_URL", cfg.TypesenseURL)
+	cfg.TypesenseAPIKey = e.str("TYPESENSE_API_KEY", cfg.TypesenseAPIKey)
+	cfg.TypesenseNodes = e.list("TYPESENSE_NODES", cfg.TypesenseNodes)
+	cfg.TypesenseInstances = e.list("TYPESENSE_INSTANCES", cfg.TypesenseInstances)
+	cfg.TypesenseHealthInterval = e.duration("TYPESENSE_HEALTH_INTERVAL", cfg.TypesenseHealthInterval)
+	cfg.TypesenseTimeouts.Request = e.duration("TYPESENSE_TIMEOUT", cfg.TypesenseTimeouts.Request)
+	cfg.TypesenseTimeouts.Export = e.duration("TYPESENSE_EXPORT_TIMEOUT", cfg.TypesenseTimeouts.Export)
+	cfg.TypesenseTimeouts.Import = e.duration("TYPESENSE_IMPORT_TIMEOUT", cfg.TypesenseTimeouts.Import)
+	cfg.TypesenseRetry.Attempts = e.int("TYPESENSE_RETRY_ATTEMPTS", cfg.TypesenseRetry.Attempts)
+	cfg.TypesenseRetry.BaseDelay = e.duration("TYPESENSE_RETRY_BASE_DELAY", cfg.TypesenseRetry.BaseDelay)
+	cfg.TypesenseRetry.MaxDelay = e.duration("TYPESENSE_RETRY_MAX_DELAY", cfg.TypesenseRetry.MaxDelay)
+	cfg.MeilisearchURL = e.str("MEILISEARCH_URL", cfg.MeilisearchURL)
+	cfg.MeilisearchAPIKey = e.str("MEILISEARCH_API_KEY", cfg.MeilisearchAPIKey)
+	cfg.OpenSearchURL = e.str("OPENSEARCH_URL", cfg.OpenSearchURL)
+	cfg.OpenSearchUsername = e.str("OPENSEARCH_USERNAME", cfg.OpenSearchUsername)
+	cfg.OpenSearchPassword = e.str("OPENSEARCH_PASSWORD", cfg.OpenSearchPassword)
+	cfg.Port = e.str("PORT", cfg.Port)
+	cfg.OffloadAfter = e.duration("OFFLOAD_AFTER", cfg.OffloadAfter)
+	cfg.DrainGracePeriod = e.duration("DRAIN_GRACE_PERIOD", cfg.DrainGracePeriod)
+	cfg.SchedulerInterval = e.duration("SCHEDULER_INTERVAL", cfg.SchedulerInterval)
+	cfg.ReconcileInterval = e.duration("RECONCILE_INTERVAL", cfg.ReconcileInterval)
+	cfg.ShutdownTimeout = e.duration("SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	cfg.ReloadMode = ReloadMode(e.str("RELOAD_MODE", string(cfg.ReloadMode)))
+	cfg.PolicyFile = e.str("POLICY_FILE", cfg.PolicyFile)
+	cfg.PrewarmProtect = e.duration("PREWARM_PROTECT", cfg.PrewarmProtect)
+	cfg.PredictivePrewarm = e.bool("PREDICTIVE_PREWARM", cfg.PredictivePrewarm)
+	cfg.PredictThreshold = e.float("PREDICT_THRESHOLD", cfg.PredictThreshold)
+	cfg.PredictLead = e.duration("PREDICT_LEAD", cfg.PredictLead)
+	cfg.PredictHistoryWeeks = e.int("PREDICT_HISTORY_WEEKS", cfg.PredictHistoryWeeks)
+	cfg.PredictMinWeeks = e.int("PREDICT_MIN_WEEKS", cfg.PredictMinWeeks)
+	cfg.Eviction = e.list("EVICTION", cfg.Eviction)
+	cfg.MemoryHighWater = e.float("MEMORY_HIGH_WATER", cfg.MemoryHighWater)
+	cfg.MemoryLowWater = e.float("MEMORY_LOW_WATER", cfg.MemoryLowWater)
+	cfg.MemoryBudgetBytes = int64(e.int("MEMORY_BUDGET_BYTES", int(cfg.MemoryBudgetBytes)))
+	cfg.MemoryCheckInterval = e.duration("MEMORY_CHECK_INTERVAL", cfg.MemoryCheckInterval)
+	cfg.MaxConcurrentReloads = e.int("MAX_CONCURRENT_RELOADS", cfg.MaxConcurrentReloads)
+	cfg.ImportFailureThreshold = e.float("IMPORT_FAILURE_THRESHOLD", cfg.ImportFailureThreshold)
+	cfg.SnapshotDir = e.str("SNAPSHOT_DIR", cfg.SnapshotDir)
+	cfg.SnapshotCompression = snapshot.Codec(e.str("SNAPSHOT_COMPRESSION", string(cfg.SnapshotCompression)))
+	cfg.SnapshotStore = e.str("SNAPSHOT_STORE", cfg.SnapshotStore)
+	cfg.S3.Endpoint = e.str("S3_ENDPOINT", cfg.S3.Endpoint)
+	cfg.S3.Region = e.str("S3_REGION", cfg.S3.Region)
+	cfg.S3.Bucket = e.str("S3_BUCKET", cfg.S3.Bucket)
+	cfg.S3.Prefix = e.str("S3_PREFIX", cfg.S3.Prefix)
+	cfg.S3.AccessKeyID = e.str("S3_ACCESS_KEY_ID", e.str("AWS_ACCESS_KEY_ID", cfg.S3.AccessKeyID))
+	cfg.S3.SecretAccessKey = e.str("S3_SECRET_ACCESS_KEY", e.str("AWS_SECRET_ACCESS_KEY", cfg.S3.SecretAccessKey))
+	cfg.S3.PathStyle = e.bool("S3_PATH_STYLE", cfg.S3.PathStyle)
+	cfg.S3.PartSize = int64(e.int("S3_PART_SIZE", int(cfg.S3.PartSize)))
+	cfg.S3.Timeout = e.duration("S3_TIMEOUT", cfg.S3.Timeout)
+	cfg.StateDBPath = e.str("STATE_DB_PATH", cfg.StateDBPath)
+	cfg.ListenAddr = e.str("LISTEN_ADDR", cfg.ListenAddr)
+
+	return e.errs
+}
+
+// env reads environment variables, falling back to the current value when
+// a variable is unset and collecting parse errors instead of exiting.
+type env struct {
+	errs []error
+}
+
+func (e *env) str(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// list reads a comma-separated list
+func (e *env) list(key string, def []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func (e *env) duration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid duration for %s: %q", key, v))
+			return def
+		}
+		return d
+	}
+	return def
+}
+
+func (e *env) int(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid int for %s: %q", key, v))
+			return def
+		}
+		return i
+	}
+	return def
+}
+
+func (e *env) bool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid bool for %s: %q", key, v))
+			return def
+		}
+		return b
+	}
+	return def
+}
+
+func (e *env) float(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("invalid float for %s: %q", key, v))
+			return def
+		}
+		return f
+	}
+	return def
+}
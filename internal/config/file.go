@@ -0,0 +1,216 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/policy"
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
+	"go.yaml.in/yaml/v2"
+)
+
+// file is the layout of the YAML config file. Its fields point into a
+// Config, so decoding the file overwrites exactly the settings it mentions
+// and leaves the defaults in place for everything else.
+type file struct {
+	Engine struct {
+		Type *string `yaml:"type"`
+		// URL and credentials apply to whichever engine is selected
+		URL      string `yaml:"url"`
+		APIKey   string `yaml:"api_key"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		// Nodes, instances, health checks, timeouts and retries are only
+		// used by Typesense
+		Nodes          *[]string      `yaml:"nodes"`
+		Instances      *[]string      `yaml:"instances"`
+		HealthInterval *time.Duration `yaml:"health_interval"`
+		Timeouts       struct {
+			Request *time.Duration `yaml:"request"`
+			Export  *time.Duration `yaml:"export"`
+			Import  *time.Duration `yaml:"import"`
+		} `yaml:"timeouts"`
+		Retry struct {
+			Attempts  *int           `yaml:"attempts"`
+			BaseDelay *time.Duration `yaml:"base_delay"`
+			MaxDelay  *time.Duration `yaml:"max_delay"`
+		} `yaml:"retry"`
+	} `yaml:"engine"`
+
+	Mode *string `yaml:"mode"`
+
+	Listen struct {
+		Port    *string `yaml:"port"`
+		Address *string `yaml:"address"`
+	} `yaml:"listen"`
+
+	Offload struct {
+		After             *time.Duration `yaml:"after"`
+		DrainGracePeriod  *time.Duration `yaml:"drain_grace_period"`
+		SchedulerInterval *time.Duration `yaml:"scheduler_interval"`
+		Eviction          *[]string      `yaml:"eviction"`
+		Memory            struct {
+			HighWater     *float64       `yaml:"high_water"`
+			LowWater      *float64       `yaml:"low_water"`
+			BudgetBytes   *int64         `yaml:"budget_bytes"`
+			CheckInterval *time.Duration `yaml:"check_interval"`
+		} `yaml:"memory"`
+	} `yaml:"offload"`
+
+	Reload struct {
+		Strategy               *ReloadMode `yaml:"strategy"`
+		MaxConcurrent          *int        `yaml:"max_concurrent"`
+		ImportFailureThreshold *float64    `yaml:"import_failure_threshold"`
+	} `yaml:"reload"`
+
+	Prewarm struct {
+		Protect    *time.Duration `yaml:"protect"`
+		Predictive struct {
+			Enabled      *bool          `yaml:"enabled"`
+			Threshold    *float64       `yaml:"threshold"`
+			Lead         *time.Duration `yaml:"lead"`
+			HistoryWeeks *int           `yaml:"history_weeks"`
+			MinWeeks     *int           `yaml:"min_weeks"`
+		} `yaml:"predictive"`
+	} `yaml:"prewarm"`
+
+	Reconcile struct {
+		Interval *time.Duration `yaml:"interval"`
+	} `yaml:"reconcile"`
+
+	Shutdown struct {
+		Timeout *time.Duration `yaml:"timeout"`
+	} `yaml:"shutdown"`
+
+	Storage struct {
+		Type            *string         `yaml:"type"`
+		Dir             *string         `yaml:"dir"`
+		Compression     *snapshot.Codec `yaml:"compression"`
+		Endpoint        *string         `yaml:"endpoint"`
+		Region          *string         `yaml:"region"`
+		Bucket          *string         `yaml:"bucket"`
+		Prefix          *string         `yaml:"prefix"`
+		AccessKeyID     *string         `yaml:"access_key_id"`
+		SecretAccessKey *string         `yaml:"secret_access_key"`
+		PathStyle       *bool           `yaml:"path_style"`
+		PartSize        *int64          `yaml:"part_size"`
+		Timeout         *time.Duration  `yaml:"timeout"`
+	} `yaml:"storage"`
+
+	State struct {
+		DBPath *string `yaml:"db_path"`
+	} `yaml:"state"`
+
+	PolicyFile *string          `yaml:"policy_file"`
+	Policies   *[]policy.Policy `yaml:"policies"`
+}
+
+func newFile(cfg *Config) *file {
+	f := &file{}
+	f.Engine.Type = &cfg.Engine
+	f.Engine.Nodes = &cfg.TypesenseNodes
+	f.Engine.Instances = &cfg.TypesenseInstances
+	f.Engine.HealthInterval = &cfg.TypesenseHealthInterval
+	f.Engine.Timeouts.Request = &cfg.TypesenseTimeouts.Request
+	f.Engine.Timeouts.Export = &cfg.TypesenseTimeouts.Export
+	f.Engine.Timeouts.Import = &cfg.TypesenseTimeouts.Import
+	f.Engine.Retry.Attempts = &cfg.TypesenseRetry.Attempts
+	f.Engine.Retry.BaseDelay = &cfg.TypesenseRetry.BaseDelay
+	f.Engine.Retry.MaxDelay = &cfg.TypesenseRetry.MaxDelay
+	f.Mode = &cfg.Mode
+	f.Listen.Port = &cfg.Port
+	f.Listen.Address = &cfg.ListenAddr
+	f.Offload.After = &cfg.OffloadAfter
+	f.Offload.DrainGracePeriod = &cfg.DrainGracePeriod
+	f.Offload.SchedulerInterval = &cfg.SchedulerInterval
+	f.Offload.Eviction = &cfg.Eviction
+	f.Offload.Memory.HighWater = &cfg.MemoryHighWater
+	f.Offload.Memory.LowWater = &cfg.MemoryLowWater
+	f.Offload.Memory.BudgetBytes = &cfg.MemoryBudgetBytes
+	f.Offload.Memory.CheckInterval = &cfg.MemoryCheckInterval
+	f.Reload.Strategy = &cfg.ReloadMode
+	f.Reload.MaxConcurrent = &cfg.MaxConcurrentReloads
+	f.Reload.ImportFailureThreshold = &cfg.ImportFailureThreshold
+	f.Prewarm.Protect = &cfg.PrewarmProtect
+	f.Prewarm.Predictive.Enabled = &cfg.PredictivePrewarm
+	f.Prewarm.Predictive.Threshold = &cfg.PredictThreshold
+	f.Prewarm.Predictive.Lead = &cfg.PredictLead
+	f.Prewarm.Predictive.HistoryWeeks = &cfg.PredictHistoryWeeks
+	f.Prewarm.Predictive.MinWeeks = &cfg.PredictMinWeeks
+	f.Reconcile.Interval = &cfg.ReconcileInterval
+	f.Shutdown.Timeout = &cfg.ShutdownTimeout
+	f.Storage.Type = &cfg.SnapshotStore
+	f.Storage.Dir = &cfg.SnapshotDir
+	f.Storage.Compression = &cfg.SnapshotCompression
+	f.Storage.Endpoint = &cfg.S3.Endpoint
+	f.Storage.Region = &cfg.S3.Region
+	f.Storage.Bucket = &cfg.S3.Bucket
+	f.Storage.Prefix = &cfg.S3.Prefix
+	f.Storage.AccessKeyID = &cfg.S3.AccessKeyID
+	f.Storage.SecretAccessKey = &cfg.S3.SecretAccessKey
+	f.Storage.PathStyle = &cfg.S3.PathStyle
+	f.Storage.PartSize = &cfg.S3.PartSize
+	f.Storage.Timeout = &cfg.S3.Timeout
+	f.State.DBPath = &cfg.StateDBPath
+	f.PolicyFile = &cfg.PolicyFile
+	f.Policies = &cfg.Policies
+	return f
+}
+
+// loadFile decodes the YAML file onto cfg. Unknown keys and type errors
+// are all reported, each with its line number.
+func loadFile(path string, cfg *Config) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	f := newFile(cfg)
+	if err := yaml.UnmarshalStrict(data, f); err != nil {
+		if te, ok := err.(*yaml.TypeError); ok {
+			for _, msg := range te.Errors {
+				msg = unknownField.ReplaceAllString(msg, "unknown setting $1")
+				errs = append(errs, fmt.Errorf("%s: %s", path, msg))
+			}
+		} else {
+			return []error{fmt.Errorf("%s: %w", path, err)}
+		}
+	}
+
+	// The connection settings belong to the engine the file selects; with
+	// ENGINE naming another one they would reach the wrong engine
+	e := f.Engine
+	override := os.Getenv("ENGINE")
+	connection := e.URL != "" || e.APIKey != "" || e.Username != "" || e.Password != ""
+	if override != "" && override != cfg.Engine && connection {
+		return append(errs, fmt.Errorf(
+			"%s: engine url and credentials are for engine %s, but ENGINE=%s; set engine.type to match or pass them as environment variables",
+			path, cfg.Engine, override,
+		))
+	}
+	switch cfg.Engine {
+	case EngineTypesense:
+		setIf(&cfg.TypesenseURL, e.URL)
+		setIf(&cfg.TypesenseAPIKey, e.APIKey)
+	case EngineMeilisearch:
+		setIf(&cfg.MeilisearchURL, e.URL)
+		setIf(&cfg.MeilisearchAPIKey, e.APIKey)
+	case EngineOpenSearch, EngineElasticsearch:
+		setIf(&cfg.OpenSearchURL, e.URL)
+		setIf(&cfg.OpenSearchUsername, e.Username)
+		setIf(&cfg.OpenSearchPassword, e.Password)
+	}
+	return errs
+}
+
+// unknownField shortens yaml's "field x not found in type struct { ... }"
+var unknownField = regexp.MustCompile(`field (\S+) not found in type .*$`)
+
+func setIf(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// watchInterval is how often the config and policy files are checked for
+// changes.
+const watchInterval = 5 * time.Second
+
+// Watch reloads the configuration, until ctx is done, whenever the process
+// receives SIGHUP or the config file at path or the policy file changes.
+// Every valid new configuration is passed to apply; an invalid one is
+// logged and the previous configuration stays in effect.
+//
+// Only the settings the running components can change in place are hot
+// reloadable; changes to any other setting are logged as needing a restart.
+func Watch(ctx context.Context, path string, current *Config, apply func(*Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		stamps := fileStamps(path, current)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				log.Printf("config reload requested by SIGHUP")
+			case <-ticker.C:
+				if maps.Equal(fileStamps(path, current), stamps) {
+					continue
+				}
+				log.Printf("config reload: file changed")
+			}
+
+			next, err := Load(path)
+			// A file caught mid-write is retried once it changes again
+			stamps = fileStamps(path, current)
+			if err != nil {
+				log.Printf("config reload rejected, keeping current configuration:\n%v", err)
+				continue
+			}
+
+			for _, setting := range restartRequired(current, next) {
+				log.Printf("config reload: %s changed, restart to apply", setting)
+			}
+			apply(next)
+			current = next
+			stamps = fileStamps(path, current)
+			logConfig(current)
+		}
+	}()
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileStamps records the modification time and size of the files the
+// configuration was read from. Missing files get a zero stamp.
+func fileStamps(path string, cfg *Config) map[string]fileStamp {
+	out := make(map[string]fileStamp)
+	for _, name := range []string{path, cfg.PolicyFile} {
+		if name == "" {
+			continue
+		}
+		var stamp fileStamp
+		if info, err := os.Stat(name); err == nil {
+			stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		out[name] = stamp
+	}
+	return out
+}
+
+// restartRequired lists the settings that differ between cur and next but
+// are only read at startup.
+func restartRequired(cur, next *Config) []string {
+	checks := []struct {
+		setting string
+		changed bool
+	}{
+		{"engine", cur.Engine != next.Engine},
+		{"mode", cur.Mode != next.Mode},
+		{"typesense", cur.TypesenseURL != next.TypesenseURL ||
+			!slices.Equal(cur.TypesenseNodes, next.TypesenseNodes) ||
+			!slices.Equal(cur.TypesenseInstances, next.TypesenseInstances) ||
+			cur.TypesenseHealthInterval != next.TypesenseHealthInterval ||
+			cur.TypesenseAPIKey != next.TypesenseAPIKey ||
+			cur.TypesenseTimeouts != next.TypesenseTimeouts ||
+			cur.TypesenseRetry != next.TypesenseRetry},
+		{"meilisearch", cur.MeilisearchURL != next.MeilisearchURL || cur.MeilisearchAPIKey != next.MeilisearchAPIKey},
+		{"opensearch", cur.OpenSearchURL != next.OpenSearchURL || cur.OpenSearchUsername != next.OpenSearchUsername || cur.OpenSearchPassword != next.OpenSearchPassword},
+		{"port", cur.Port != next.Port},
+		{"listen address", cur.ListenAddr != next.ListenAddr},
+		{"reconcile interval", cur.ReconcileInterval != next.ReconcileInterval},
+		{"shutdown timeout", cur.ShutdownTimeout != next.ShutdownTimeout},
+		{"predictive prewarm", cur.PredictivePrewarm != next.PredictivePrewarm ||
+			cur.PredictThreshold != next.PredictThreshold ||
+			cur.PredictLead != next.PredictLead ||
+			cur.PredictHistoryWeeks != next.PredictHistoryWeeks ||
+			cur.PredictMinWeeks != next.PredictMinWeeks},
+		{"memory eviction", cur.EvictMemory() != next.EvictMemory()},
+		{"import failure threshold", cur.ImportFailureThreshold != next.ImportFailureThreshold},
+		{"snapshot settings", cur.SnapshotDir != next.SnapshotDir ||
+			cur.SnapshotCompression != next.SnapshotCompression ||
+			cur.SnapshotStore != next.SnapshotStore ||
+			cur.S3 != next.S3},
+		{"state db path", cur.StateDBPath != next.StateDBPath},
+	}
+
+	var out []string
+	for _, c := range checks {
+		if c.changed {
+			out = append(out, c.setting)
+		}
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"* * * * mon-",
+		"1,,2 * * * *",
+		"@never",
+	}
+	for _, expr := range tests {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2026, time.March, 4, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 4, 10, 18, 0, 0, time.UTC)},
+		{"17 * * * *", time.Date(2026, 3, 4, 11, 17, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, 3, 4, 10, 25, 0, 0, time.UTC)},
+		{"0 8-18/2 * * *", time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)},
+		{"0,45 9,10 * * *", time.Date(2026, 3, 4, 10, 45, 0, 0, time.UTC)},
+		{"30 7 * * MON-FRI", time.Date(2026, 3, 5, 7, 30, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+		{"@hourly", time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+
+		// Both day fields restricted: either may match
+		{"0 0 13 * FRI", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 5 * SAT", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		// A day field starting with * has to match as well
+		{"0 0 */2 * MON", time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */2 * TUE", time.Date(2026, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 10 * */3", time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1", time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15 * *", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	s, err := Parse("30 7 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2026, time.March, 28, 12, 0, 0, 0, berlin)
+	// The next morning is the first one in summer time
+	want := time.Date(2026, time.March, 29, 5, 30, 0, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
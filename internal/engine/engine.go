@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"io"
+)
+
+// Engine is the set of operations Hiberstack needs from a search engine
+// to offload a collection to cold storage and bring it back.
+type Engine interface {
+	// GetSchema returns the raw collection definition as stored in the snapshot.
+	GetSchema(ctx context.Context, collection string) ([]byte, error)
+	// Export streams every document of the collection as JSONL.
+	Export(ctx context.Context, collection string) (io.ReadCloser, error)
+	// CreateCollection recreates a collection from a snapshot schema.
+	CreateCollection(ctx context.Context, schema []byte) error
+	// ImportDocuments loads JSONL documents into an existing collection.
+	// Per-document failures are reported in the result rather than as an
+	// error; the error is reserved for the import as a whole failing.
+	ImportDocuments(ctx context.Context, collection string, r io.Reader) (*ImportResult, error)
+	Delete(ctx context.Context, collection string) error
+	// DocumentCount returns how many documents the collection holds.
+	DocumentCount(ctx context.Context, collection string) (int64, error)
+	// ListCollections returns the names of the collections currently loaded.
+	// Engines spread over instances return the collections of the
+	// reachable ones with a *PartialError naming the others.
+	ListCollections(ctx context.Context) ([]string, error)
+	Health(ctx context.Context) error
+	// Info identifies the engine a snapshot was taken from.
+	Info(ctx context.Context) (Info, error)
+
+	PathMapper
+}
+
+type Info struct {
+	Name    string
+	Version string
+}
+
+// PathMapper maps a proxied request path to the collection it targets.
+// Every engine lays out its HTTP API differently, so the proxy defers to
+// the adapter. An empty result means the request is not collection-scoped.
+type PathMapper interface {
+	CollectionFromPath(path string) string
+}
+
+// Extras is implemented by engines that keep collection-scoped objects
+// outside the schema, such as synonyms, curation rules or aliases. Each
+// entry is stored as its own file in the snapshot and handed back to
+// RestoreExtras after the documents have been imported.
+type Extras interface {
+	ExportExtras(ctx context.Context, collection string) (map[string][]byte, error)
+	RestoreExtras(ctx context.Context, collection string, extras map[string][]byte) error
+}
+
+// Memory is an engine's memory use in bytes.
+type Memory struct {
+	Used  int64
+	Total int64
+}
+
+// MemoryReporter is implemented by engines that expose their memory use.
+// Memory-pressure eviction is only available for these engines.
+type MemoryReporter interface {
+	MemoryUsage(ctx context.Context) (Memory, error)
+}
+
+// InstanceMemoryReporter is implemented by engines that spread collections
+// over instances with memory of their own, keyed by the instance names
+// recorded as placements in state.db. Memory-pressure eviction then
+// applies to each instance on its own.
+type InstanceMemoryReporter interface {
+	InstanceMemoryUsage(ctx context.Context) (map[string]Memory, error)
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Errors adapters wrap so that lifecycle code can branch on what went
+// wrong without knowing the engine's API.
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrUnauthorized  = errors.New("unauthorized")
+)
+
+// PartialError comes with the result of a call spread over several engine
+// instances when some of them could not be reached. The result covers
+// every other instance.
+type PartialError struct {
+	Unreachable []string
+	Err         error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("instances %s unreachable: %v", strings.Join(e.Unreachable, ", "), e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// Unknown reports whether a ListCollections result that came with err
+// says nothing about a collection placed on the given instance ("" for
+// none). Only a *PartialError leaves some collections known.
+func Unknown(err error, instance string) bool {
+	if err == nil {
+		return false
+	}
+	var pe *PartialError
+	if !errors.As(err, &pe) {
+		return true
+	}
+	return instance == "" || slices.Contains(pe.Unreachable, instance)
+}
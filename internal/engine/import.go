@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// MaxImportErrors caps how many per-document errors an ImportResult keeps.
+const MaxImportErrors = 10
+
+type ImportResult struct {
+	Succeeded int
+	Failed    int
+	// Errors holds the first MaxImportErrors failures in import order.
+	Errors []ImportError
+}
+
+type ImportError struct {
+	// Line is the 1-based line of the document in the imported JSONL,
+	// counting blank lines; see Documents.
+	Line    int
+	Message string
+}
+
+func (r *ImportResult) AddSuccess() {
+	r.Succeeded++
+}
+
+func (r *ImportResult) AddFailure(line int, message string) {
+	r.Failed++
+	if len(r.Errors) < MaxImportErrors {
+		r.Errors = append(r.Errors, ImportError{Line: line, Message: message})
+	}
+}
+
+// FailureRatio is the share of documents that failed to import.
+func (r *ImportResult) FailureRatio() float64 {
+	total := r.Succeeded + r.Failed
+	if total == 0 {
+		return 0
+	}
+	return float64(r.Failed) / float64(total)
+}
+
+func (r *ImportResult) String() string {
+	s := fmt.Sprintf("%d succeeded, %d failed", r.Succeeded, r.Failed)
+	for _, e := range r.Errors {
+		s += fmt.Sprintf("; line %d: %s", e.Line, e.Message)
+	}
+	return s
+}
+
+// Documents passes the documents of a JSONL stream through with blank
+// lines left out, so every engine sees exactly one document per line, and
+// maps a document back to its line in the original stream. All adapters
+// number import errors this way.
+type Documents struct {
+	r       *bufio.Reader
+	pending []byte
+	err     error
+
+	mu    sync.Mutex
+	count int
+	// blanks holds, for every blank line, the number of documents read
+	// before it
+	blanks []int
+}
+
+func NewDocuments(r io.Reader) *Documents {
+	return &Documents{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+func (d *Documents) Read(p []byte) (int, error) {
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		line, err := d.r.ReadBytes('\n')
+		d.err = err
+		if len(line) == 0 {
+			continue
+		}
+
+		d.mu.Lock()
+		if len(bytes.TrimSpace(line)) == 0 {
+			d.blanks = append(d.blanks, d.count)
+		} else {
+			d.count++
+			d.pending = line
+		}
+		d.mu.Unlock()
+	}
+
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// Line returns the 1-based line in the original stream of the nth
+// document, with n counting from 1.
+func (d *Documents) Line(n int) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Blank lines seen before the nth document was read
+	return n + sort.SearchInts(d.blanks, n)
+}
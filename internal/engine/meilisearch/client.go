@@ -0,0 +1,42 @@
+package meilisearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+var _ engine.Engine = (*Client)(nil)
+
+type Client struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+
+	// PollInterval is how often task status is checked while waiting
+	// for Meilisearch's async task queue.
+	PollInterval time.Duration
+	// TaskTimeout bounds how long a single task is waited for.
+	TaskTimeout time.Duration
+}
+
+func New(url, key string) *Client {
+	return &Client{
+		BaseURL:      url,
+		APIKey:       key,
+		Client:       &http.Client{},
+		PollInterval: 200 * time.Millisecond,
+		TaskTimeout:  30 * time.Minute,
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, url string, body io.Reader) *http.Request {
+	req, _ := http.NewRequestWithContext(ctx, method, url, body)
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return req
+}
@@ -0,0 +1,16 @@
+package meilisearch
+
+import (
+	"context"
+	"fmt"
+)
+
+func (c *Client) DocumentCount(ctx context.Context, collection string) (int64, error) {
+	var stats struct {
+		NumberOfDocuments int64 `json:"numberOfDocuments"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/indexes/%s/stats", c.BaseURL, collection), &stats); err != nil {
+		return 0, fmt.Errorf("failed to fetch document count: %w", err)
+	}
+	return stats.NumberOfDocuments, nil
+}
@@ -0,0 +1,70 @@
+package meilisearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+// CreateCollection recreates the index described by a snapshot schema and
+// applies its settings, waiting for both tasks to complete. If the settings
+// cannot be applied the new index is deleted again, so a failed create
+// never leaves an index behind.
+func (c *Client) CreateCollection(ctx context.Context, raw []byte) error {
+	var s schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	if s.UID == "" {
+		return fmt.Errorf("invalid schema: missing uid")
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"uid":        s.UID,
+		"primaryKey": s.PrimaryKey,
+	})
+	req := c.newRequest(ctx, "POST", fmt.Sprintf("%s/indexes", c.BaseURL), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := c.enqueued(ctx, resp, "index creation"); err != nil {
+		return err
+	}
+
+	if len(s.Settings) == 0 {
+		return nil
+	}
+
+	if err := c.applySettings(ctx, s.UID, s.Settings); err != nil {
+		// Remove the index even when ctx was what made the update fail
+		if derr := c.Delete(context.WithoutCancel(ctx), s.UID); derr != nil && !errors.Is(derr, engine.ErrNotFound) {
+			log.Printf("meilisearch create rollback failed index=%s err=%v", s.UID, derr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *Client) applySettings(ctx context.Context, uid string, settings []byte) error {
+	req := c.newRequest(ctx, "PATCH", fmt.Sprintf("%s/indexes/%s/settings", c.BaseURL, uid), bytes.NewReader(settings))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = c.enqueued(ctx, resp, "settings update")
+	return err
+}
@@ -0,0 +1,23 @@
+package meilisearch
+
+import (
+	"context"
+	"fmt"
+)
+
+func (c *Client) Delete(ctx context.Context, collection string) error {
+	req := c.newRequest(ctx,
+		"DELETE",
+		fmt.Sprintf("%s/indexes/%s", c.BaseURL, collection),
+		nil,
+	)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = c.enqueued(ctx, resp, "delete")
+	return err
+}
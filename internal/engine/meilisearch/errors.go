@@ -0,0 +1,66 @@
+package meilisearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+// Error is a request Meilisearch answered with an unexpected status, or a
+// task that failed. It matches engine.ErrNotFound, engine.ErrAlreadyExists
+// or engine.ErrUnauthorized through errors.Is where the error code or
+// status maps to one of them. Status is zero for failed tasks.
+type Error struct {
+	Op      string
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Status == 0 {
+		return fmt.Sprintf("%s failed: %s (%s)", e.Op, e.Message, e.Code)
+	}
+	return fmt.Sprintf("%s failed: status %d: %s", e.Op, e.Status, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch e.Code {
+	case "index_not_found", "document_not_found", "task_not_found":
+		return engine.ErrNotFound
+	case "index_already_exists":
+		return engine.ErrAlreadyExists
+	case "missing_authorization_header", "invalid_api_key":
+		return engine.ErrUnauthorized
+	}
+	switch e.Status {
+	case http.StatusNotFound:
+		return engine.ErrNotFound
+	case http.StatusConflict:
+		return engine.ErrAlreadyExists
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return engine.ErrUnauthorized
+	}
+	return nil
+}
+
+// responseError reads Meilisearch's error body, {"message", "code", ...},
+// from resp without closing it.
+func responseError(op string, resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+
+	var msg struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	}
+	if json.Unmarshal(body, &msg) != nil || msg.Message == "" {
+		msg.Message = string(body)
+	}
+	if msg.Message == "" {
+		msg.Message = http.StatusText(resp.StatusCode)
+	}
+	return &Error{Op: op, Status: resp.StatusCode, Code: msg.Code, Message: msg.Message}
+}
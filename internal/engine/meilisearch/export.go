@@ -0,0 +1,56 @@
+package meilisearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// pageSize is the number of documents or indexes fetched per request.
+const pageSize = 1000
+
+// Export pages through the documents endpoint and streams the results as
+// JSONL, so large indexes are never held in memory at once.
+func (c *Client) Export(ctx context.Context, collection string) (io.ReadCloser, error) {
+	// Fail fast if the index does not exist
+	var idx struct {
+		UID string `json:"uid"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/indexes/%s", c.BaseURL, collection), &idx); err != nil {
+		return nil, fmt.Errorf("export failed: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		w := bufio.NewWriter(pw)
+		pw.CloseWithError(c.exportPages(ctx, collection, w))
+	}()
+
+	return pr, nil
+}
+
+func (c *Client) exportPages(ctx context.Context, collection string, w *bufio.Writer) error {
+	for offset := 0; ; offset += pageSize {
+		var page struct {
+			Results []json.RawMessage `json:"results"`
+			Total   int               `json:"total"`
+		}
+
+		url := fmt.Sprintf("%s/indexes/%s/documents?offset=%d&limit=%d", c.BaseURL, collection, offset, pageSize)
+		if err := c.getJSON(ctx, url, &page); err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+
+		for _, doc := range page.Results {
+			w.Write(doc)
+			w.WriteByte('\n')
+		}
+
+		if len(page.Results) < pageSize || offset+len(page.Results) >= page.Total {
+			return w.Flush()
+		}
+	}
+}
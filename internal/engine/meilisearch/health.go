@@ -0,0 +1,57 @@
+package meilisearch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+func (c *Client) ListCollections(ctx context.Context) ([]string, error) {
+	var out []string
+
+	for offset := 0; ; offset += pageSize {
+		var page struct {
+			Results []struct {
+				UID string `json:"uid"`
+			} `json:"results"`
+			Total int `json:"total"`
+		}
+
+		url := fmt.Sprintf("%s/indexes?offset=%d&limit=%d", c.BaseURL, offset, pageSize)
+		if err := c.getJSON(ctx, url, &page); err != nil {
+			return nil, fmt.Errorf("list indexes failed: %w", err)
+		}
+
+		for _, idx := range page.Results {
+			out = append(out, idx.UID)
+		}
+
+		if len(page.Results) < pageSize || len(out) >= page.Total {
+			return out, nil
+		}
+	}
+}
+
+func (c *Client) Health(ctx context.Context) error {
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/health", c.BaseURL), &body); err != nil {
+		return err
+	}
+	if body.Status != "available" {
+		return fmt.Errorf("meilisearch unhealthy: %s", body.Status)
+	}
+	return nil
+}
+
+func (c *Client) Info(ctx context.Context) (engine.Info, error) {
+	var v struct {
+		PkgVersion string `json:"pkgVersion"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/version", c.BaseURL), &v); err != nil {
+		return engine.Info{}, fmt.Errorf("failed to fetch version: %w", err)
+	}
+	return engine.Info{Name: "meilisearch", Version: v.PkgVersion}, nil
+}
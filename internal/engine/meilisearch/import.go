@@ -0,0 +1,42 @@
+package meilisearch
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+// ImportDocuments adds the documents in a single task. Meilisearch applies
+// a document batch atomically, so per-document results are derived from
+// the received and indexed counts of the finished task. Blank lines are
+// left out before sending, as for every engine.
+func (c *Client) ImportDocuments(ctx context.Context, collection string, r io.Reader) (*engine.ImportResult, error) {
+	req := c.newRequest(ctx,
+		"POST",
+		fmt.Sprintf("%s/indexes/%s/documents", c.BaseURL, collection),
+		engine.NewDocuments(r),
+	)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	t, err := c.enqueued(ctx, resp, "import")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &engine.ImportResult{Succeeded: t.Details.IndexedDocuments}
+	if missing := t.Details.ReceivedDocuments - t.Details.IndexedDocuments; missing > 0 {
+		result.Failed = missing
+		result.Errors = []engine.ImportError{{
+			Message: fmt.Sprintf("%d of %d documents not indexed by task %d", missing, t.Details.ReceivedDocuments, t.UID),
+		}}
+	}
+	return result, nil
+}
@@ -0,0 +1,12 @@
+package meilisearch
+
+import "strings"
+
+func (c *Client) CollectionFromPath(path string) string {
+	// Expected: /indexes/{uid}/...
+	parts := strings.Split(path, "/")
+	if len(parts) >= 3 && parts[1] == "indexes" {
+		return parts[2]
+	}
+	return ""
+}
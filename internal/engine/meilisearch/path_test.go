@@ -0,0 +1,27 @@
+package meilisearch
+
+import "testing"
+
+func TestCollectionFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/indexes/movies/search", "movies"},
+		{"/indexes/movies/documents/42", "movies"},
+		{"/indexes/movies/settings/synonyms", "movies"},
+		{"/indexes/movies", "movies"},
+		{"/indexes", ""},
+		{"/indexes/", ""},
+		{"/multi-search", ""},
+		{"/tasks/12", ""},
+		{"/", ""},
+		{"", ""},
+	}
+	c := &Client{}
+	for _, tt := range tests {
+		if got := c.CollectionFromPath(tt.path); got != tt.want {
+			t.Errorf("CollectionFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
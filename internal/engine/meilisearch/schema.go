@@ -0,0 +1,46 @@
+package meilisearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// schema is the snapshot representation of an index. Meilisearch has no
+// single schema document, so the index identity and its settings are
+// stored together.
+type schema struct {
+	UID        string          `json:"uid"`
+	PrimaryKey *string         `json:"primaryKey"`
+	Settings   json.RawMessage `json:"settings"`
+}
+
+func (c *Client) GetSchema(ctx context.Context, collection string) ([]byte, error) {
+	var s schema
+
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/indexes/%s", c.BaseURL, collection), &s); err != nil {
+		return nil, fmt.Errorf("failed to fetch index: %w", err)
+	}
+
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/indexes/%s/settings", c.BaseURL, collection), &s.Settings); err != nil {
+		return nil, fmt.Errorf("failed to fetch settings: %w", err)
+	}
+
+	return json.Marshal(s)
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, out any) error {
+	req := c.newRequest(ctx, "GET", url, nil)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return responseError("GET "+req.URL.Path, resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
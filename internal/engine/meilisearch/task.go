@@ -0,0 +1,90 @@
+package meilisearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type taskRef struct {
+	TaskUID int64 `json:"taskUid"`
+}
+
+type task struct {
+	UID     int64  `json:"uid"`
+	Status  string `json:"status"`
+	Details struct {
+		ReceivedDocuments int `json:"receivedDocuments"`
+		IndexedDocuments  int `json:"indexedDocuments"`
+	} `json:"details"`
+	Error *struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+// enqueued decodes the task reference Meilisearch returns for every
+// asynchronous write and waits for that task to finish.
+func (c *Client) enqueued(ctx context.Context, resp *http.Response, op string) (*task, error) {
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, responseError(op, resp)
+	}
+
+	var ref taskRef
+	if err := json.NewDecoder(resp.Body).Decode(&ref); err != nil {
+		return nil, fmt.Errorf("%s failed: %w", op, err)
+	}
+	return c.waitTask(ctx, ref.TaskUID, op)
+}
+
+func (c *Client) waitTask(ctx context.Context, uid int64, op string) (*task, error) {
+	deadline := time.Now().Add(c.TaskTimeout)
+
+	for {
+		t, err := c.getTask(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+
+		switch t.Status {
+		case "succeeded":
+			return t, nil
+		case "failed", "canceled":
+			if t.Error != nil {
+				return nil, &Error{Op: op, Code: t.Error.Code, Message: t.Error.Message}
+			}
+			return nil, fmt.Errorf("%s failed: task %d %s", op, uid, t.Status)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s failed: task %d still %s after %s", op, uid, t.Status, c.TaskTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s failed: task %d still %s: %w", op, uid, t.Status, ctx.Err())
+		case <-time.After(c.PollInterval):
+		}
+	}
+}
+
+func (c *Client) getTask(ctx context.Context, uid int64) (*task, error) {
+	req := c.newRequest(ctx, "GET", fmt.Sprintf("%s/tasks/%d", c.BaseURL, uid), nil)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, responseError(fmt.Sprintf("fetch task %d", uid), resp)
+	}
+
+	var t task
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
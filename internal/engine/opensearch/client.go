@@ -0,0 +1,93 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+var _ engine.Engine = (*Client)(nil)
+
+// Client talks to OpenSearch or Elasticsearch. Both expose the same index,
+// _bulk and search APIs; the few places where they differ (point-in-time
+// handling) are resolved from the distribution reported by GET /.
+type Client struct {
+	BaseURL  string
+	Username string
+	Password string
+	Client   *http.Client
+
+	flavorOnce sync.Once
+	flavor     string
+	flavorErr  error
+}
+
+const (
+	flavorOpenSearch    = "opensearch"
+	flavorElasticsearch = "elasticsearch"
+)
+
+func New(url, username, password string) *Client {
+	return &Client{
+		BaseURL:  url,
+		Username: username,
+		Password: password,
+		Client:   &http.Client{},
+	}
+}
+
+func (c *Client) newRequest(ctx context.Context, method, url string, body io.Reader) *http.Request {
+	req, _ := http.NewRequestWithContext(ctx, method, url, body)
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// do sends the request and decodes a 2xx JSON body into out. Any other
+// status is returned as an *Error.
+func (c *Client) do(req *http.Request, out any) error {
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return responseError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) getFlavor(ctx context.Context) (string, error) {
+	c.flavorOnce.Do(func() {
+		var info struct {
+			Version struct {
+				Distribution string `json:"distribution"`
+			} `json:"version"`
+		}
+		if err := c.do(c.newRequest(ctx, "GET", c.BaseURL+"/", nil), &info); err != nil {
+			c.flavorErr = fmt.Errorf("detect distribution failed: %w", err)
+			return
+		}
+
+		if info.Version.Distribution == flavorOpenSearch {
+			c.flavor = flavorOpenSearch
+		} else {
+			c.flavor = flavorElasticsearch
+		}
+	})
+	return c.flavor, c.flavorErr
+}
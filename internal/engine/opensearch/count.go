@@ -0,0 +1,35 @@
+package opensearch
+
+import (
+	"context"
+	"fmt"
+)
+
+// DocumentCount refreshes the index first, so documents indexed since the
+// last refresh are counted too.
+func (c *Client) DocumentCount(ctx context.Context, collection string) (int64, error) {
+	if err := c.refresh(ctx, collection); err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		Count int64 `json:"count"`
+	}
+
+	req := c.newRequest(ctx, "GET", fmt.Sprintf("%s/%s/_count", c.BaseURL, collection), nil)
+	if err := c.do(req, &resp); err != nil {
+		return 0, fmt.Errorf("failed to fetch document count: %w", err)
+	}
+	return resp.Count, nil
+}
+
+// refresh makes every document indexed so far visible to search. Without
+// it, _count and exports miss recent writes, all of them if the index has
+// refresh_interval set to -1.
+func (c *Client) refresh(ctx context.Context, collection string) error {
+	req := c.newRequest(ctx, "POST", fmt.Sprintf("%s/%s/_refresh", c.BaseURL, collection), nil)
+	if err := c.do(req, nil); err != nil {
+		return fmt.Errorf("refresh failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+func (c *Client) CreateCollection(ctx context.Context, raw []byte) error {
+	var s schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	if s.Index == "" {
+		return fmt.Errorf("invalid schema: missing index")
+	}
+
+	body := map[string]any{
+		"settings": s.Settings,
+		"mappings": s.Mappings,
+	}
+	if len(s.Aliases) > 0 {
+		body["aliases"] = s.Aliases
+	}
+	buf, _ := json.Marshal(body)
+
+	req := c.newRequest(ctx, "PUT", fmt.Sprintf("%s/%s", c.BaseURL, s.Index), bytes.NewReader(buf))
+	if err := c.do(req, nil); err != nil {
+		return fmt.Errorf("index creation failed: %w", err)
+	}
+	return nil
+}
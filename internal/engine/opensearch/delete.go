@@ -0,0 +1,14 @@
+package opensearch
+
+import (
+	"context"
+	"fmt"
+)
+
+func (c *Client) Delete(ctx context.Context, collection string) error {
+	req := c.newRequest(ctx, "DELETE", fmt.Sprintf("%s/%s", c.BaseURL, collection), nil)
+	if err := c.do(req, nil); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	return nil
+}
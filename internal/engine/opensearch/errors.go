@@ -0,0 +1,67 @@
+package opensearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+// Error is a request OpenSearch or Elasticsearch answered with a non-2xx
+// status. It matches engine.ErrNotFound, engine.ErrAlreadyExists or
+// engine.ErrUnauthorized through errors.Is where the error type or status
+// maps to one of them.
+type Error struct {
+	Status int
+	// Type is the error type, e.g. index_not_found_exception
+	Type    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("status %d: %s", e.Status, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch e.Type {
+	case "index_not_found_exception":
+		return engine.ErrNotFound
+	case "resource_already_exists_exception":
+		// Answered with 400 rather than 409
+		return engine.ErrAlreadyExists
+	}
+	switch e.Status {
+	case http.StatusNotFound:
+		return engine.ErrNotFound
+	case http.StatusConflict:
+		return engine.ErrAlreadyExists
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return engine.ErrUnauthorized
+	}
+	return nil
+}
+
+// responseError reads the error body, {"error": {"type", "reason"}}, from
+// resp without closing it. The body is kept as the message when it has no
+// reason.
+func responseError(resp *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+
+	var msg struct {
+		Error struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+	e := &Error{Status: resp.StatusCode, Message: string(body)}
+	if json.Unmarshal(body, &msg) == nil && msg.Error.Reason != "" {
+		e.Type = msg.Error.Type
+		e.Message = msg.Error.Type + ": " + msg.Error.Reason
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
@@ -0,0 +1,223 @@
+package opensearch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+)
+
+const (
+	// pageSize is the number of documents fetched per search request.
+	pageSize  = 1000
+	keepAlive = "5m"
+)
+
+type hit struct {
+	ID      string          `json:"_id"`
+	Routing string          `json:"_routing,omitempty"`
+	Source  json.RawMessage `json:"_source"`
+	Sort    json.RawMessage `json:"sort,omitempty"`
+}
+
+// document is one line of the exported JSONL. The envelope keeps the
+// document id and routing so _bulk can restore them exactly.
+type document struct {
+	ID      string          `json:"_id"`
+	Routing string          `json:"_routing,omitempty"`
+	Source  json.RawMessage `json:"_source"`
+}
+
+type searchResponse struct {
+	PitID    string `json:"pit_id"`
+	ScrollID string `json:"_scroll_id"`
+	Hits     struct {
+		Hits []hit `json:"hits"`
+	} `json:"hits"`
+}
+
+// Export streams every document of the index as JSONL through a
+// point-in-time with search_after, opened with _pit on Elasticsearch and
+// _search/point_in_time on OpenSearch. The first page is fetched before
+// committing to it: versions without PIT, or without the _shard_doc sort
+// it relies on (Elasticsearch before 7.12), fall back to the scroll API.
+// The index is refreshed first so the export includes documents not yet
+// visible to search.
+func (c *Client) Export(ctx context.Context, collection string) (io.ReadCloser, error) {
+	flavor, err := c.getFlavor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.refresh(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+
+	first, err := c.openPIT(ctx, flavor, collection)
+	if err == nil {
+		go func() {
+			w := bufio.NewWriter(pw)
+			pw.CloseWithError(c.exportPIT(ctx, flavor, first, w))
+		}()
+		return pr, nil
+	}
+	log.Printf("opensearch pit unavailable collection=%s err=%v, using scroll", collection, err)
+
+	scroll, err := c.openScroll(ctx, collection)
+	if err != nil {
+		return nil, fmt.Errorf("export failed: %w", err)
+	}
+
+	go func() {
+		w := bufio.NewWriter(pw)
+		pw.CloseWithError(c.exportScroll(ctx, scroll, w))
+	}()
+	return pr, nil
+}
+
+// openPIT opens a point-in-time on the index and fetches its first page.
+// If that page fails the PIT is closed again.
+func (c *Client) openPIT(ctx context.Context, flavor, collection string) (*searchResponse, error) {
+	var resp struct {
+		ID    string `json:"id"`
+		PitID string `json:"pit_id"`
+	}
+
+	path := "/_pit"
+	if flavor == flavorOpenSearch {
+		path = "/_search/point_in_time"
+	}
+	req := c.newRequest(ctx, "POST", fmt.Sprintf("%s/%s%s?keep_alive=%s", c.BaseURL, collection, path, keepAlive), nil)
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+	pitID := resp.ID
+	if flavor == flavorOpenSearch {
+		pitID = resp.PitID
+	}
+
+	first, err := c.searchPIT(ctx, pitID, nil)
+	if err != nil {
+		c.closePIT(ctx, flavor, pitID)
+		return nil, err
+	}
+	if first.PitID == "" {
+		first.PitID = pitID
+	}
+	return first, nil
+}
+
+// closePIT releases the point-in-time, even when the export itself was
+// cancelled.
+func (c *Client) closePIT(ctx context.Context, flavor, pitID string) {
+	body, _ := json.Marshal(map[string]string{"id": pitID})
+	path := "/_pit"
+	if flavor == flavorOpenSearch {
+		body, _ = json.Marshal(map[string][]string{"pit_id": {pitID}})
+		path = "/_search/point_in_time"
+	}
+	if err := c.do(c.newRequest(context.WithoutCancel(ctx), "DELETE", c.BaseURL+path, bytes.NewReader(body)), nil); err != nil {
+		log.Printf("opensearch close pit failed: %v", err)
+	}
+}
+
+func (c *Client) searchPIT(ctx context.Context, pitID string, after json.RawMessage) (*searchResponse, error) {
+	query := map[string]any{
+		"size": pageSize,
+		"pit":  map[string]string{"id": pitID, "keep_alive": keepAlive},
+		"sort": []any{map[string]string{"_shard_doc": "asc"}},
+	}
+	if after != nil {
+		query["search_after"] = after
+	}
+	body, _ := json.Marshal(query)
+
+	var resp searchResponse
+	if err := c.do(c.newRequest(ctx, "POST", c.BaseURL+"/_search", bytes.NewReader(body)), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) exportPIT(ctx context.Context, flavor string, resp *searchResponse, w *bufio.Writer) error {
+	pitID := resp.PitID
+	defer func() { c.closePIT(ctx, flavor, pitID) }()
+
+	for {
+		hits := resp.Hits.Hits
+		if err := writeHits(w, hits); err != nil {
+			return err
+		}
+		if len(hits) < pageSize {
+			return w.Flush()
+		}
+
+		next, err := c.searchPIT(ctx, pitID, hits[len(hits)-1].Sort)
+		if err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		if next.PitID != "" {
+			pitID = next.PitID
+		}
+		resp = next
+	}
+}
+
+func (c *Client) openScroll(ctx context.Context, collection string) (*searchResponse, error) {
+	body, _ := json.Marshal(map[string]any{
+		"size": pageSize,
+		"sort": []string{"_doc"},
+	})
+
+	var resp searchResponse
+	req := c.newRequest(ctx, "POST", fmt.Sprintf("%s/%s/_search?scroll=%s", c.BaseURL, collection, keepAlive), bytes.NewReader(body))
+	if err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) exportScroll(ctx context.Context, resp *searchResponse, w *bufio.Writer) error {
+	scrollID := resp.ScrollID
+	defer func() {
+		body, _ := json.Marshal(map[string]string{"scroll_id": scrollID})
+		if err := c.do(c.newRequest(context.WithoutCancel(ctx), "DELETE", c.BaseURL+"/_search/scroll", bytes.NewReader(body)), nil); err != nil {
+			log.Printf("opensearch clear scroll failed: %v", err)
+		}
+	}()
+
+	for {
+		hits := resp.Hits.Hits
+		if err := writeHits(w, hits); err != nil {
+			return err
+		}
+		if len(hits) == 0 {
+			return w.Flush()
+		}
+
+		body, _ := json.Marshal(map[string]string{"scroll": keepAlive, "scroll_id": scrollID})
+		resp = &searchResponse{}
+		if err := c.do(c.newRequest(ctx, "POST", c.BaseURL+"/_search/scroll", bytes.NewReader(body)), resp); err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		if resp.ScrollID != "" {
+			scrollID = resp.ScrollID
+		}
+	}
+}
+
+func writeHits(w *bufio.Writer, hits []hit) error {
+	for _, h := range hits {
+		line, err := json.Marshal(document{ID: h.ID, Routing: h.Routing, Source: h.Source})
+		if err != nil {
+			return err
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package opensearch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+func (c *Client) ListCollections(ctx context.Context) ([]string, error) {
+	var rows []struct {
+		Index string `json:"index"`
+	}
+
+	req := c.newRequest(ctx, "GET", c.BaseURL+"/_cat/indices?format=json&h=index", nil)
+	if err := c.do(req, &rows); err != nil {
+		return nil, fmt.Errorf("list indices failed: %w", err)
+	}
+
+	out := make([]string, 0, len(rows))
+	for _, r := range rows {
+		// Hidden and system indices are never hibernated
+		if strings.HasPrefix(r.Index, ".") {
+			continue
+		}
+		out = append(out, r.Index)
+	}
+	return out, nil
+}
+
+func (c *Client) Health(ctx context.Context) error {
+	var body struct {
+		Status string `json:"status"`
+	}
+
+	req := c.newRequest(ctx, "GET", c.BaseURL+"/_cluster/health", nil)
+	if err := c.do(req, &body); err != nil {
+		return err
+	}
+	if body.Status == "red" {
+		return fmt.Errorf("cluster health is red")
+	}
+	return nil
+}
+
+func (c *Client) Info(ctx context.Context) (engine.Info, error) {
+	var info struct {
+		Version struct {
+			Number       string `json:"number"`
+			Distribution string `json:"distribution"`
+		} `json:"version"`
+	}
+	if err := c.do(c.newRequest(ctx, "GET", c.BaseURL+"/", nil), &info); err != nil {
+		return engine.Info{}, fmt.Errorf("failed to fetch version: %w", err)
+	}
+
+	name := info.Version.Distribution
+	if name == "" {
+		name = flavorElasticsearch
+	}
+	return engine.Info{Name: name, Version: info.Version.Number}, nil
+}
@@ -0,0 +1,112 @@
+package opensearch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+const (
+	bulkMaxDocs  = 1000
+	bulkMaxBytes = 5 << 20
+)
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string          `json:"_id"`
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// ImportDocuments replays an export through the _bulk API in batches and
+// refreshes the index so documents are searchable once reload completes.
+func (c *Client) ImportDocuments(ctx context.Context, collection string, r io.Reader) (*engine.ImportResult, error) {
+	docs := engine.NewDocuments(r)
+	scanner := bufio.NewScanner(docs)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+
+	result := &engine.ImportResult{}
+	var batch bytes.Buffer
+	var lines []int
+	n := 0
+
+	for scanner.Scan() {
+		n++
+		line := docs.Line(n)
+		raw := scanner.Bytes()
+
+		var doc document
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			result.AddFailure(line, fmt.Sprintf("invalid document: %v", err))
+			continue
+		}
+
+		action := map[string]string{"_index": collection, "_id": doc.ID}
+		if doc.Routing != "" {
+			action["routing"] = doc.Routing
+		}
+		meta, _ := json.Marshal(map[string]any{"index": action})
+
+		batch.Write(meta)
+		batch.WriteByte('\n')
+		batch.Write(doc.Source)
+		batch.WriteByte('\n')
+		lines = append(lines, line)
+
+		if len(lines) >= bulkMaxDocs || batch.Len() >= bulkMaxBytes {
+			if err := c.bulk(ctx, &batch, lines, result); err != nil {
+				return nil, err
+			}
+			batch.Reset()
+			lines = lines[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(lines) > 0 {
+		if err := c.bulk(ctx, &batch, lines, result); err != nil {
+			return nil, err
+		}
+	}
+
+	req := c.newRequest(ctx, "POST", fmt.Sprintf("%s/%s/_refresh", c.BaseURL, collection), nil)
+	if err := c.do(req, nil); err != nil {
+		return nil, fmt.Errorf("refresh failed: %w", err)
+	}
+	return result, nil
+}
+
+// bulk sends one batch and records the outcome of every item. lines holds
+// the JSONL line of each document in the batch, in order.
+func (c *Client) bulk(ctx context.Context, body *bytes.Buffer, lines []int, result *engine.ImportResult) error {
+	req := c.newRequest(ctx, "POST", c.BaseURL+"/_bulk", body)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	var resp bulkResponse
+	if err := c.do(req, &resp); err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+	if len(resp.Items) != len(lines) {
+		return fmt.Errorf("import failed: sent %d documents, got %d results", len(lines), len(resp.Items))
+	}
+
+	for i, item := range resp.Items {
+		for _, res := range item {
+			if res.Status > 299 {
+				result.AddFailure(lines[i], fmt.Sprintf("document %s: %s", res.ID, res.Error))
+			} else {
+				result.AddSuccess()
+			}
+		}
+	}
+	return nil
+}
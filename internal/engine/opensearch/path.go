@@ -0,0 +1,19 @@
+package opensearch
+
+import "strings"
+
+func (c *Client) CollectionFromPath(path string) string {
+	// Expected: /{index}/_search, /{index}/_doc/{id}, /{index}, ...
+	// Cluster-level APIs start with an underscore, and multi-index or
+	// wildcard targets cannot be mapped to a single collection.
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	index := parts[1]
+	if index == "" || strings.HasPrefix(index, "_") || strings.ContainsAny(index, ",*") {
+		return ""
+	}
+	return index
+}
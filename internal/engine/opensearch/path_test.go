@@ -0,0 +1,29 @@
+package opensearch
+
+import "testing"
+
+func TestCollectionFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/logs/_search", "logs"},
+		{"/logs/_doc/1", "logs"},
+		{"/logs/_bulk", "logs"},
+		{"/logs", "logs"},
+		{"/logs/", "logs"},
+		{"/_search", ""},
+		{"/_cluster/health", ""},
+		{"/_bulk", ""},
+		{"/logs,metrics/_search", ""},
+		{"/logs-*/_search", ""},
+		{"/", ""},
+		{"", ""},
+	}
+	c := &Client{}
+	for _, tt := range tests {
+		if got := c.CollectionFromPath(tt.path); got != tt.want {
+			t.Errorf("CollectionFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// schema is the snapshot representation of an index: its mappings,
+// user-settable settings and aliases.
+type schema struct {
+	Index    string          `json:"index"`
+	Mappings json.RawMessage `json:"mappings"`
+	Settings map[string]any  `json:"settings"`
+	Aliases  json.RawMessage `json:"aliases,omitempty"`
+}
+
+// Settings that are assigned by the cluster and rejected on index creation.
+var readOnlySettings = []string{
+	"uuid",
+	"version",
+	"creation_date",
+	"creation_date_string",
+	"provided_name",
+	"history",
+	"resize",
+}
+
+func (c *Client) GetSchema(ctx context.Context, collection string) ([]byte, error) {
+	var resp map[string]struct {
+		Aliases  json.RawMessage `json:"aliases"`
+		Mappings json.RawMessage `json:"mappings"`
+		Settings map[string]any  `json:"settings"`
+	}
+
+	req := c.newRequest(ctx, "GET", fmt.Sprintf("%s/%s", c.BaseURL, collection), nil)
+	if err := c.do(req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch schema: %w", err)
+	}
+
+	idx, ok := resp[collection]
+	if !ok {
+		return nil, fmt.Errorf("failed to fetch schema: index %s not in response", collection)
+	}
+
+	if index, ok := idx.Settings["index"].(map[string]any); ok {
+		for _, k := range readOnlySettings {
+			delete(index, k)
+		}
+	}
+
+	return json.Marshal(schema{
+		Index:    collection,
+		Mappings: idx.Mappings,
+		Settings: idx.Settings,
+		Aliases:  idx.Aliases,
+	})
+}
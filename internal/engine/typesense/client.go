@@ -1,17 +1,70 @@
 package typesense
 
-import "net/http"
+import (
+	"net/http"
+	"time"
 
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+var _ engine.Engine = (*Client)(nil)
+
+// Client sends every call to the cluster leader, falling back to another
+// healthy node when the leader is unknown or unreachable.
 type Client struct {
-	BaseURL string
+	Cluster *Cluster
 	APIKey  string
 	Client  *http.Client
+
+	Timeouts Timeouts
+	Retry    Retry
 }
 
-func New(url, key string) *Client {
-	return &Client{
-		BaseURL: url,
-		APIKey:  key,
-		Client:  &http.Client{},
+// Timeouts bound each kind of call, including its retries. Zero means no
+// limit beyond the caller's context.
+type Timeouts struct {
+	// Request applies to everything except exports and imports.
+	Request time.Duration
+	// Export covers streaming every document of a collection out.
+	Export time.Duration
+	// Import covers streaming documents into a collection.
+	Import time.Duration
+}
+
+// Retry controls how transient failures (connection refused, 5xx and 429)
+// are retried: up to Attempts tries in total, waiting an exponentially
+// growing, jittered delay between BaseDelay and MaxDelay.
+type Retry struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+var (
+	DefaultTimeouts = Timeouts{
+		Request: 30 * time.Second,
+		Export:  time.Hour,
+		Import:  time.Hour,
+	}
+	DefaultRetry = Retry{
+		Attempts:  4,
+		BaseDelay: 200 * time.Millisecond,
+		MaxDelay:  5 * time.Second,
 	}
+)
+
+// New returns a client for the Typesense nodes, given as base URLs. Node
+// health is only tracked once Cluster.Start has been called.
+func New(nodes []string, key string) (*Client, error) {
+	cluster, err := NewCluster(nodes, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Cluster:  cluster,
+		APIKey:   key,
+		Client:   &http.Client{},
+		Timeouts: DefaultTimeouts,
+		Retry:    DefaultRetry,
+	}, nil
 }
@@ -0,0 +1,208 @@
+package typesense
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+)
+
+// raftLeader is the Raft state /debug reports on the leader.
+const raftLeader = 1
+
+// Cluster tracks the health and Raft role of the nodes of a Typesense
+// cluster. A single Typesense server is a cluster of one.
+type Cluster struct {
+	nodes  []*node
+	apiKey string
+	client *http.Client
+	// next rotates reads across healthy nodes
+	next atomic.Uint64
+}
+
+type node struct {
+	url     *url.URL
+	healthy atomic.Bool
+	leader  atomic.Bool
+}
+
+// NewCluster expects each node as a base URL, e.g. http://ts-1:8108. Nodes
+// count as healthy until a health check says otherwise.
+func NewCluster(nodes []string, apiKey string) (*Cluster, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("typesense: no nodes configured")
+	}
+
+	c := &Cluster{apiKey: apiKey, client: &http.Client{}}
+	for _, raw := range nodes {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("typesense: invalid node URL %q", raw)
+		}
+		n := &node{url: u}
+		n.healthy.Store(true)
+		c.nodes = append(c.nodes, n)
+	}
+	return c, nil
+}
+
+// Start checks every node once and then every interval until ctx is done.
+func (c *Cluster) Start(ctx context.Context, interval time.Duration) {
+	c.checkAll(ctx, interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkAll(ctx, interval)
+			}
+		}
+	}()
+}
+
+// Targets lists the nodes to send a request to, best first. Writes go to
+// the leader; followers would only forward them. Reads are spread across
+// healthy nodes. With no healthy node left every node is tried anyway.
+func (c *Cluster) Targets(write bool) []*url.URL {
+	if write {
+		return []*url.URL{c.leader().url}
+	}
+
+	var healthy []*url.URL
+	for _, n := range c.nodes {
+		if n.healthy.Load() {
+			healthy = append(healthy, n.url)
+		}
+	}
+	if len(healthy) == 0 {
+		for _, n := range c.nodes {
+			healthy = append(healthy, n.url)
+		}
+	}
+
+	start := int(c.next.Add(1) % uint64(len(healthy)))
+	return append(healthy[start:], healthy[:start]...)
+}
+
+// MarkDown takes a node that could not be reached out of rotation until
+// its next successful health check.
+func (c *Cluster) MarkDown(u *url.URL) {
+	for _, n := range c.nodes {
+		if n.url.Host == u.Host && n.healthy.Swap(false) {
+			log.Printf("typesense node=%s marked down", n.url.Host)
+			metrics.EngineNodeHealthy.WithLabelValues(n.url.Host).Set(0)
+		}
+	}
+}
+
+// leader is the healthy leader, else the first healthy node, else the
+// first node.
+func (c *Cluster) leader() *node {
+	var fallback *node
+	for _, n := range c.nodes {
+		if !n.healthy.Load() {
+			continue
+		}
+		if n.leader.Load() {
+			return n
+		}
+		if fallback == nil {
+			fallback = n
+		}
+	}
+	if fallback == nil {
+		fallback = c.nodes[0]
+	}
+	return fallback
+}
+
+func (c *Cluster) checkAll(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, n := range c.nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.check(ctx, n)
+		}()
+	}
+	wg.Wait()
+}
+
+// check asks the node for /health and, if it is healthy, for its Raft
+// state through /debug.
+func (c *Cluster) check(ctx context.Context, n *node) {
+	var health struct {
+		OK bool `json:"ok"`
+	}
+	err := c.getJSON(ctx, n, "/health", &health)
+	if err == nil && !health.OK {
+		err = fmt.Errorf("not ok")
+	}
+
+	leader := false
+	if err == nil {
+		var debug struct {
+			State int `json:"state"`
+		}
+		if err := c.getJSON(ctx, n, "/debug", &debug); err != nil {
+			log.Printf("typesense node=%s raft state unknown: %v", n.url.Host, err)
+		}
+		leader = debug.State == raftLeader
+	}
+
+	healthy := err == nil
+	if was := n.healthy.Swap(healthy); was != healthy {
+		if healthy {
+			log.Printf("typesense node=%s healthy", n.url.Host)
+		} else {
+			log.Printf("typesense node=%s unhealthy: %v", n.url.Host, err)
+		}
+	}
+	if was := n.leader.Swap(leader); leader && !was {
+		log.Printf("typesense node=%s is leader", n.url.Host)
+	}
+
+	metrics.EngineNodeHealthy.WithLabelValues(n.url.Host).Set(gauge(healthy))
+	metrics.EngineNodeLeader.WithLabelValues(n.url.Host).Set(gauge(leader))
+}
+
+func (c *Cluster) getJSON(ctx context.Context, n *node, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", n.url.JoinPath(path).String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-TYPESENSE-API-KEY", c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// /health answers 503 with {"ok": false} on an unhealthy node
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return responseError(path, resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func gauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,16 @@
+package typesense
+
+import (
+	"context"
+	"fmt"
+)
+
+func (c *Client) DocumentCount(ctx context.Context, collection string) (int64, error) {
+	var col struct {
+		NumDocuments int64 `json:"num_documents"`
+	}
+	if err := c.getJSON(ctx, "document count", "/collections/"+collection, &col); err != nil {
+		return 0, fmt.Errorf("failed to fetch document count: %w", err)
+	}
+	return col.NumDocuments, nil
+}
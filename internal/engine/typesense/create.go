@@ -1,29 +1,23 @@
 package typesense
 
-import (
-	"bytes"
-	"fmt"
-	"net/http"
-)
+import "context"
 
-func (c *Client) CreateCollection(schema []byte) error {
-	req, _ := http.NewRequest(
-		"POST",
-		fmt.Sprintf("%s/collections", c.BaseURL),
-		bytes.NewReader(schema),
-	)
-	req.Header.Set("X-TYPESENSE-API-KEY", c.APIKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.Client.Do(req)
+// CreateCollection fails with engine.ErrAlreadyExists if the collection
+// is already there. A create that may have reached Typesense is not
+// retried, so that error never comes from our own earlier attempt and a
+// collection we created is always reported as created.
+func (c *Client) CreateCollection(ctx context.Context, schema []byte) error {
+	resp, err := c.do(ctx, request{
+		op:          "create collection",
+		method:      "POST",
+		path:        "/collections",
+		body:        schema,
+		contentType: "application/json",
+		timeout:     c.Timeouts.Request,
+		noReplay:    true,
+	})
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 201 {
-		return fmt.Errorf("collection creation failed")
-	}
-
-	return nil
+	return resp.Body.Close()
 }
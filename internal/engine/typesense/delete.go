@@ -1,26 +1,17 @@
 package typesense
 
-import (
-	"fmt"
-	"net/http"
-)
+import "context"
 
-func (c *Client) Delete(collection string) error {
-	req, _ := http.NewRequest(
-		"DELETE",
-		fmt.Sprintf("%s/collections/%s", c.BaseURL, collection),
-		nil,
-	)
-	req.Header.Set("X-TYPESENSE-API-KEY", c.APIKey)
-
-	resp, err := c.Client.Do(req)
+// Delete fails with engine.ErrNotFound if the collection does not exist.
+func (c *Client) Delete(ctx context.Context, collection string) error {
+	resp, err := c.do(ctx, request{
+		op:      "delete collection",
+		method:  "DELETE",
+		path:    "/collections/" + collection,
+		timeout: c.Timeouts.Request,
+	})
 	if err != nil {
 		return err
 	}
-	resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("delete failed")
-	}
-	return nil
+	return resp.Body.Close()
 }
@@ -0,0 +1,56 @@
+package typesense
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+// Error is a call Typesense answered with a non-2xx status. It matches
+// engine.ErrNotFound, engine.ErrAlreadyExists or engine.ErrUnauthorized
+// through errors.Is where the status maps to one of them.
+type Error struct {
+	Op      string
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("typesense %s: status %d: %s", e.Op, e.Status, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	switch e.Status {
+	case http.StatusNotFound:
+		return engine.ErrNotFound
+	case http.StatusConflict:
+		return engine.ErrAlreadyExists
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return engine.ErrUnauthorized
+	}
+	return nil
+}
+
+func (e *Error) retryable() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+// responseError consumes and closes resp, keeping Typesense's message.
+func responseError(op string, resp *http.Response) *Error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+
+	var msg struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &msg) != nil || msg.Message == "" {
+		msg.Message = string(body)
+	}
+	if msg.Message == "" {
+		msg.Message = http.StatusText(resp.StatusCode)
+	}
+	return &Error{Op: op, Status: resp.StatusCode, Message: msg.Message}
+}
@@ -1,28 +1,21 @@
 package typesense
 
 import (
-	"fmt"
+	"context"
 	"io"
-	"net/http"
 )
 
-func (c *Client) Export(collection string) (io.ReadCloser, error) {
-	req, _ := http.NewRequest(
-		"GET",
-		fmt.Sprintf("%s/collections/%s/documents/export", c.BaseURL, collection),
-		nil,
-	)
-	req.Header.Set("X-TYPESENSE-API-KEY", c.APIKey)
-
-	resp, err := c.Client.Do(req)
+// Export streams the collection's documents. The export timeout covers
+// reading the whole body, not just the start of the response.
+func (c *Client) Export(ctx context.Context, collection string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, request{
+		op:      "export",
+		method:  "GET",
+		path:    "/collections/" + collection + "/documents/export",
+		timeout: c.Timeouts.Export,
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	if resp.StatusCode != 200 {
-		resp.Body.Close()
-		return nil, fmt.Errorf("export failed")
-	}
-
 	return resp.Body, nil
 }
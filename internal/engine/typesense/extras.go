@@ -0,0 +1,146 @@
+package typesense
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+var _ engine.Extras = (*Client)(nil)
+
+const (
+	extraSynonyms  = "synonyms"
+	extraOverrides = "overrides"
+	extraAliases   = "aliases"
+)
+
+type alias struct {
+	Name           string `json:"name"`
+	CollectionName string `json:"collection_name"`
+}
+
+// ExportExtras captures the synonyms, overrides and aliases that Typesense
+// drops together with the collection, so a reload restores identical
+// search behaviour.
+func (c *Client) ExportExtras(ctx context.Context, collection string) (map[string][]byte, error) {
+	var synonyms struct {
+		Synonyms []json.RawMessage `json:"synonyms"`
+	}
+	if err := c.getJSON(ctx, "list synonyms", "/collections/"+collection+"/synonyms", &synonyms); err != nil {
+		return nil, fmt.Errorf("failed to fetch synonyms: %w", err)
+	}
+
+	var overrides struct {
+		Overrides []json.RawMessage `json:"overrides"`
+	}
+	if err := c.getJSON(ctx, "list overrides", "/collections/"+collection+"/overrides", &overrides); err != nil {
+		return nil, fmt.Errorf("failed to fetch overrides: %w", err)
+	}
+
+	var aliases struct {
+		Aliases []alias `json:"aliases"`
+	}
+	if err := c.getJSON(ctx, "list aliases", "/aliases", &aliases); err != nil {
+		return nil, fmt.Errorf("failed to fetch aliases: %w", err)
+	}
+
+	owned := []alias{}
+	for _, a := range aliases.Aliases {
+		if a.CollectionName == collection {
+			owned = append(owned, a)
+		}
+	}
+
+	out := make(map[string][]byte, 3)
+	out[extraSynonyms], _ = json.Marshal(nonNil(synonyms.Synonyms))
+	out[extraOverrides], _ = json.Marshal(nonNil(overrides.Overrides))
+	out[extraAliases], _ = json.Marshal(owned)
+	return out, nil
+}
+
+func (c *Client) RestoreExtras(ctx context.Context, collection string, extras map[string][]byte) error {
+	if raw, ok := extras[extraSynonyms]; ok {
+		if err := c.restoreByID(ctx, collection, "synonyms", raw); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := extras[extraOverrides]; ok {
+		if err := c.restoreByID(ctx, collection, "overrides", raw); err != nil {
+			return err
+		}
+	}
+
+	if raw, ok := extras[extraAliases]; ok {
+		var aliases []alias
+		if err := json.Unmarshal(raw, &aliases); err != nil {
+			return fmt.Errorf("invalid aliases: %w", err)
+		}
+		for _, a := range aliases {
+			if err := c.restoreAlias(ctx, collection, a.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreByID upserts every object of a synonyms or overrides listing
+// through PUT /collections/{name}/{kind}/{id}.
+func (c *Client) restoreByID(ctx context.Context, collection, kind string, raw []byte) error {
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return fmt.Errorf("invalid %s: %w", kind, err)
+	}
+
+	for _, item := range items {
+		var id string
+		if err := json.Unmarshal(item["id"], &id); err != nil || id == "" {
+			return fmt.Errorf("invalid %s: entry without id", kind)
+		}
+		delete(item, "id")
+
+		body, _ := json.Marshal(item)
+		path := fmt.Sprintf("/collections/%s/%s/%s", collection, kind, url.PathEscape(id))
+		if err := c.sendJSON(ctx, "restore "+kind, "PUT", path, body); err != nil {
+			return fmt.Errorf("restore %s %s failed: %w", kind, id, err)
+		}
+	}
+	return nil
+}
+
+// restoreAlias points the alias back at the collection unless it has been
+// re-pointed at another collection while this one was cold.
+func (c *Client) restoreAlias(ctx context.Context, collection, name string) error {
+	path := "/aliases/" + url.PathEscape(name)
+
+	var current alias
+	err := c.getJSON(ctx, "get alias", path, &current)
+	switch {
+	case errors.Is(err, engine.ErrNotFound):
+	case err != nil:
+		return fmt.Errorf("restore alias %s failed: %w", name, err)
+	case current.CollectionName != "" && current.CollectionName != collection:
+		log.Printf("typesense alias %s now points to %s, not restoring to %s", name, current.CollectionName, collection)
+		return nil
+	}
+
+	body, _ := json.Marshal(map[string]string{"collection_name": collection})
+	if err := c.sendJSON(ctx, "restore alias", "PUT", path, body); err != nil {
+		return fmt.Errorf("restore alias %s failed: %w", name, err)
+	}
+	return nil
+}
+
+func nonNil(items []json.RawMessage) []json.RawMessage {
+	if items == nil {
+		return []json.RawMessage{}
+	}
+	return items
+}
@@ -0,0 +1,60 @@
+package typesense
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+func (c *Client) ListCollections(ctx context.Context) ([]string, error) {
+	var collections []struct {
+		Name string `json:"name"`
+	}
+	if err := c.getJSON(ctx, "list collections", "/collections", &collections); err != nil {
+		return nil, err
+	}
+
+	out := make([]string, 0, len(collections))
+	for _, col := range collections {
+		out = append(out, col.Name)
+	}
+	return out, nil
+}
+
+// Health is not retried; a failing check should be reported as such.
+func (c *Client) Health(ctx context.Context) error {
+	resp, err := c.do(ctx, request{
+		op:      "health",
+		method:  "GET",
+		path:    "/health",
+		timeout: c.Timeouts.Request,
+		once:    true,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		OK bool `json:"ok"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+	if !body.OK {
+		return fmt.Errorf("typesense unhealthy")
+	}
+	return nil
+}
+
+func (c *Client) Info(ctx context.Context) (engine.Info, error) {
+	var debug struct {
+		Version string `json:"version"`
+	}
+	if err := c.getJSON(ctx, "debug", "/debug", &debug); err != nil {
+		return engine.Info{}, fmt.Errorf("failed to fetch version: %w", err)
+	}
+	return engine.Info{Name: "typesense", Version: debug.Version}, nil
+}
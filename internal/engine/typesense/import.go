@@ -1,32 +1,64 @@
 package typesense
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
-)
 
-func (c *Client) ImportDocuments(collection string, r io.Reader) error {
-	req, _ := http.NewRequest(
-		"POST",
-		fmt.Sprintf("%s/collections/%s/documents/import?action=upsert", c.BaseURL, collection),
-		r,
-	)
-	req.Header.Set("X-TYPESENSE-API-KEY", c.APIKey)
-	req.Header.Set("Content-Type", "text/plain")
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
 
-	resp, err := c.Client.Do(req)
+// ImportDocuments streams the documents to Typesense and parses the
+// per-line results. Typesense answers 200 even when individual documents
+// are rejected, so the body is the only place those failures show up.
+// The documents are streamed, so a failed import is not retried.
+func (c *Client) ImportDocuments(ctx context.Context, collection string, r io.Reader) (*engine.ImportResult, error) {
+	docs := engine.NewDocuments(r)
+	resp, err := c.do(ctx, request{
+		op:          "import",
+		method:      "POST",
+		path:        "/collections/" + collection + "/documents/import?action=upsert",
+		stream:      docs,
+		contentType: "text/plain",
+		timeout:     c.Timeouts.Import,
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("import failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		buf := new(bytes.Buffer)
-		buf.ReadFrom(resp.Body)
-		return fmt.Errorf("import failed: %s", buf.String())
+	result := &engine.ImportResult{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+
+	// One result per document, in order
+	line := 0
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		line++
+
+		var res struct {
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			return nil, fmt.Errorf("import failed: unreadable result line %d: %w", line, err)
+		}
+
+		if res.Success {
+			result.AddSuccess()
+		} else {
+			result.AddFailure(docs.Line(line), res.Error)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("import failed: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
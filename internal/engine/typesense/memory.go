@@ -0,0 +1,47 @@
+package typesense
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+)
+
+var _ engine.MemoryReporter = (*Client)(nil)
+
+// MemoryUsage reads /metrics.json. Used is the memory actively held by
+// Typesense rather than by the whole host; /stats.json only carries
+// request rates and latencies, so it is not consulted.
+func (c *Client) MemoryUsage(ctx context.Context) (engine.Memory, error) {
+	// Typesense reports every metric as a string
+	var metrics map[string]any
+	if err := c.getJSON(ctx, "metrics", "/metrics.json", &metrics); err != nil {
+		return engine.Memory{}, fmt.Errorf("failed to fetch metrics: %w", err)
+	}
+
+	used, err := metricBytes(metrics, "typesense_memory_active_bytes")
+	if err != nil {
+		return engine.Memory{}, err
+	}
+	total, err := metricBytes(metrics, "system_memory_total_bytes")
+	if err != nil {
+		return engine.Memory{}, err
+	}
+	return engine.Memory{Used: used, Total: total}, nil
+}
+
+func metricBytes(metrics map[string]any, name string) (int64, error) {
+	switch v := metrics[name].(type) {
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %q", name, v)
+		}
+		return n, nil
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("metrics.json has no %s", name)
+	}
+}
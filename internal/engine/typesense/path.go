@@ -0,0 +1,12 @@
+package typesense
+
+import "strings"
+
+func (c *Client) CollectionFromPath(path string) string {
+	// Expected: /collections/{name}/...
+	parts := strings.Split(path, "/")
+	if len(parts) >= 3 && parts[1] == "collections" {
+		return parts[2]
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package typesense
+
+import "testing"
+
+func TestCollectionFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/collections/products/documents/search", "products"},
+		{"/collections/products/documents/import", "products"},
+		{"/collections/products", "products"},
+		{"/collections/products/", "products"},
+		{"/collections", ""},
+		{"/collections/", ""},
+		{"/multi_search", ""},
+		{"/health", ""},
+		{"/", ""},
+		{"", ""},
+	}
+	c := &Client{}
+	for _, tt := range tests {
+		if got := c.CollectionFromPath(tt.path); got != tt.want {
+			t.Errorf("CollectionFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package typesense
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+)
+
+var (
+	_ engine.Engine         = (*Pool)(nil)
+	_ engine.Extras         = (*Pool)(nil)
+	_ engine.MemoryReporter = (*Pool)(nil)
+
+	_ engine.InstanceMemoryReporter = (*Pool)(nil)
+)
+
+// Placements records which instance each collection is loaded on.
+// SetPlacement fails for collections it does not track.
+type Placements interface {
+	Placement(collection string) string
+	SetPlacement(collection, instance string) error
+}
+
+// Pool spreads collections over independent Typesense servers. Each
+// collection lives on one instance: a reload creates it on the instance
+// with the most free memory and records that placement, and every later
+// call for the collection goes to the same instance. Instances are named
+// by their host.
+type Pool struct {
+	instances  []*Client
+	byName     map[string]*Client
+	placements Placements
+
+	// missing remembers until when a collection without a placement was
+	// found on no instance, so calls for it do not probe every instance
+	mu      sync.Mutex
+	missing map[string]time.Time
+}
+
+// missingTTL bounds how long a collection found on no instance is taken to
+// be missing. Collections created on an instance behind Hiberstack's back
+// are found again after this, if the reconciler has not placed them first.
+const missingTTL = 30 * time.Second
+
+func NewPool(instances []*Client, placements Placements) (*Pool, error) {
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("typesense: no instances configured")
+	}
+
+	p := &Pool{
+		instances:  instances,
+		byName:     make(map[string]*Client, len(instances)),
+		placements: placements,
+		missing:    make(map[string]time.Time),
+	}
+	for _, c := range instances {
+		name := c.name()
+		if _, ok := p.byName[name]; ok {
+			return nil, fmt.Errorf("typesense: duplicate instance %s", name)
+		}
+		p.byName[name] = c
+	}
+	return p, nil
+}
+
+// name identifies the instance in placements.
+func (c *Client) name() string {
+	return c.Cluster.nodes[0].url.Host
+}
+
+// CreateCollection places the collection on the instance with the most
+// free memory before creating it there, so a rollback or the proxy already
+// finds it while it is being imported. The placement is only dropped when
+// the create provably never ran; a timeout or 5xx may still have created
+// the collection, and forgetting it would orphan it on the instance.
+func (p *Pool) CreateCollection(ctx context.Context, schema []byte) error {
+	var meta struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(schema, &meta); err != nil || meta.Name == "" {
+		return fmt.Errorf("typesense: schema has no collection name")
+	}
+
+	c, err := p.place(ctx)
+	if err != nil {
+		return err
+	}
+	if err := p.placements.SetPlacement(meta.Name, c.name()); err != nil {
+		return fmt.Errorf("record placement of %s: %w", meta.Name, err)
+	}
+	p.setMissing(meta.Name, false)
+	log.Printf("typesense collection=%s placed on instance=%s", meta.Name, c.name())
+	metrics.PlacementsTotal.WithLabelValues(c.name()).Inc()
+
+	err = c.CreateCollection(ctx, schema)
+	switch {
+	case errors.Is(err, engine.ErrAlreadyExists):
+		// It lives wherever it was created before, not necessarily here
+		p.clearPlacement(meta.Name)
+		if _, ownerErr := p.owner(ctx, meta.Name); ownerErr != nil {
+			log.Printf("typesense collection=%s existing copy not found: %v", meta.Name, ownerErr)
+		}
+	case neverCreated(err):
+		p.clearPlacement(meta.Name)
+	}
+	return err
+}
+
+// neverCreated reports whether a failed create is known not to have
+// created anything: the connection was refused or Typesense rejected the
+// request.
+func neverCreated(err error) bool {
+	var te *Error
+	if errors.As(err, &te) {
+		return te.Status >= 400 && te.Status < 500
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// place picks the instance with the most free memory. Instances that
+// cannot report their memory use are left out.
+func (p *Pool) place(ctx context.Context) (*Client, error) {
+	var (
+		best *Client
+		free int64
+	)
+	for _, c := range p.instances {
+		mem, err := c.MemoryUsage(ctx)
+		if err != nil {
+			log.Printf("typesense instance=%s skipped for placement: %v", c.name(), err)
+			continue
+		}
+		if best == nil || mem.Total-mem.Used > free {
+			best, free = c, mem.Total-mem.Used
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("typesense: no instance available for placement")
+	}
+	return best, nil
+}
+
+// owner returns the instance the collection is placed on. A collection
+// without a recorded placement, e.g. one created before the pool was
+// configured, is looked up on every instance and its placement recorded;
+// if it is on none, that is remembered for missingTTL.
+func (p *Pool) owner(ctx context.Context, collection string) (*Client, error) {
+	if c, ok := p.byName[p.placements.Placement(collection)]; ok {
+		return c, nil
+	}
+	notFound := fmt.Errorf("collection %s is not on any instance: %w", collection, engine.ErrNotFound)
+	if p.knownMissing(collection) {
+		return nil, notFound
+	}
+
+	var lastErr error
+	for _, c := range p.instances {
+		_, err := c.GetSchema(ctx, collection)
+		if errors.Is(err, engine.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if err := p.placements.SetPlacement(collection, c.name()); err != nil {
+			log.Printf("typesense collection=%s unable to record placement: %v", collection, err)
+		}
+		log.Printf("typesense collection=%s found on instance=%s", collection, c.name())
+		return c, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	p.setMissing(collection, true)
+	return nil, notFound
+}
+
+func (p *Pool) knownMissing(collection string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until, ok := p.missing[collection]
+	return ok && time.Now().Before(until)
+}
+
+// setMissing records whether the collection is on no instance, dropping
+// expired entries on the way.
+func (p *Pool) setMissing(collection string, missing bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for name, until := range p.missing {
+		if !now.Before(until) {
+			delete(p.missing, name)
+		}
+	}
+	if missing {
+		p.missing[collection] = now.Add(missingTTL)
+	} else {
+		delete(p.missing, collection)
+	}
+}
+
+func (p *Pool) clearPlacement(collection string) {
+	if err := p.placements.SetPlacement(collection, ""); err != nil {
+		log.Printf("typesense collection=%s unable to clear placement: %v", collection, err)
+	}
+}
+
+func (p *Pool) GetSchema(ctx context.Context, collection string) ([]byte, error) {
+	c, err := p.owner(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetSchema(ctx, collection)
+}
+
+func (p *Pool) Export(ctx context.Context, collection string) (io.ReadCloser, error) {
+	c, err := p.owner(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return c.Export(ctx, collection)
+}
+
+func (p *Pool) ImportDocuments(ctx context.Context, collection string, r io.Reader) (*engine.ImportResult, error) {
+	c, err := p.owner(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return c.ImportDocuments(ctx, collection, r)
+}
+
+// Delete removes the collection from its instance and clears the
+// placement, since the collection no longer lives anywhere.
+func (p *Pool) Delete(ctx context.Context, collection string) error {
+	c, err := p.owner(ctx, collection)
+	if err == nil {
+		err = c.Delete(ctx, collection)
+	}
+	if err == nil || errors.Is(err, engine.ErrNotFound) {
+		p.clearPlacement(collection)
+	}
+	return err
+}
+
+func (p *Pool) DocumentCount(ctx context.Context, collection string) (int64, error) {
+	c, err := p.owner(ctx, collection)
+	if err != nil {
+		return 0, err
+	}
+	return c.DocumentCount(ctx, collection)
+}
+
+func (p *Pool) ExportExtras(ctx context.Context, collection string) (map[string][]byte, error) {
+	c, err := p.owner(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return c.ExportExtras(ctx, collection)
+}
+
+func (p *Pool) RestoreExtras(ctx context.Context, collection string, extras map[string][]byte) error {
+	c, err := p.owner(ctx, collection)
+	if err != nil {
+		return err
+	}
+	return c.RestoreExtras(ctx, collection, extras)
+}
+
+// ListCollections lists the collections of every instance. An instance
+// that cannot be listed is left out rather than fail the call for all of
+// them, and named in the *PartialError returned with the rest, so callers
+// do not take its collections for gone. Collections found without a
+// recorded placement, such as ones created through the proxy, are placed
+// on the instance they were found on.
+func (p *Pool) ListCollections(ctx context.Context) ([]string, error) {
+	var (
+		out     []string
+		partial engine.PartialError
+	)
+	for _, c := range p.instances {
+		names, err := c.ListCollections(ctx)
+		if err != nil {
+			log.Printf("typesense instance=%s left out of listing: %v", c.name(), err)
+			metrics.InstanceListFailuresTotal.WithLabelValues(c.name()).Inc()
+			partial.Unreachable = append(partial.Unreachable, c.name())
+			partial.Err = err
+			continue
+		}
+		for _, name := range names {
+			if p.placements.Placement(name) == "" {
+				if err := p.placements.SetPlacement(name, c.name()); err != nil {
+					log.Printf("typesense collection=%s unable to record placement: %v", name, err)
+				}
+			}
+		}
+		out = append(out, names...)
+	}
+	if len(partial.Unreachable) > 0 {
+		return out, &partial
+	}
+	return out, nil
+}
+
+func (p *Pool) Health(ctx context.Context) error {
+	for _, c := range p.instances {
+		if err := c.Health(ctx); err != nil {
+			return fmt.Errorf("instance %s: %w", c.name(), err)
+		}
+	}
+	return nil
+}
+
+// Info describes the first instance; all instances are expected to run
+// the same Typesense version.
+func (p *Pool) Info(ctx context.Context) (engine.Info, error) {
+	return p.instances[0].Info(ctx)
+}
+
+// MemoryUsage reports the instance using the largest share of its memory.
+// Adding the instances up would hide one running out of memory behind the
+// free memory of the others.
+func (p *Pool) MemoryUsage(ctx context.Context) (engine.Memory, error) {
+	usage, err := p.InstanceMemoryUsage(ctx)
+	if err != nil {
+		return engine.Memory{}, err
+	}
+
+	var fullest engine.Memory
+	for _, mem := range usage {
+		if fullest.Total == 0 || float64(mem.Used)/float64(mem.Total) > float64(fullest.Used)/float64(fullest.Total) {
+			fullest = mem
+		}
+	}
+	return fullest, nil
+}
+
+// InstanceMemoryUsage reports the memory of each instance, so eviction
+// frees memory on the instance that needs it. Instances that cannot report
+// their memory are left out; it fails only if none can.
+func (p *Pool) InstanceMemoryUsage(ctx context.Context) (map[string]engine.Memory, error) {
+	usage := make(map[string]engine.Memory, len(p.instances))
+	var lastErr error
+	for _, c := range p.instances {
+		mem, err := c.MemoryUsage(ctx)
+		if err != nil {
+			log.Printf("typesense instance=%s memory unknown: %v", c.name(), err)
+			lastErr = fmt.Errorf("instance %s: %w", c.name(), err)
+			continue
+		}
+		usage[c.name()] = mem
+	}
+	if len(usage) == 0 {
+		return nil, lastErr
+	}
+	return usage, nil
+}
+
+func (p *Pool) CollectionFromPath(path string) string {
+	return p.instances[0].CollectionFromPath(path)
+}
+
+// Targets serves requests that are not collection-scoped, such as creating
+// a collection, from the first instance.
+func (p *Pool) Targets(write bool) []*url.URL {
+	return p.instances[0].Cluster.Targets(write)
+}
+
+// CollectionTargets routes a collection to the instance it is placed on.
+// Unplaced collections, including offloaded ones, go to the first
+// instance, whose 404 lets the proxy trigger a reload.
+func (p *Pool) CollectionTargets(collection string, write bool) []*url.URL {
+	if c, ok := p.byName[p.placements.Placement(collection)]; ok {
+		return c.Cluster.Targets(write)
+	}
+	return p.Targets(write)
+}
+
+func (p *Pool) MarkDown(node *url.URL) {
+	for _, c := range p.instances {
+		c.Cluster.MarkDown(node)
+	}
+}
+
+// Start runs the health checks of every instance until ctx is done.
+func (p *Pool) Start(ctx context.Context, interval time.Duration) {
+	for _, c := range p.instances {
+		c.Cluster.Start(ctx, interval)
+	}
+}
@@ -0,0 +1,193 @@
+package typesense
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// request is one Typesense API call.
+type request struct {
+	// op names the call in errors and logs
+	op     string
+	method string
+	path   string
+	// body is sent again on every attempt
+	body []byte
+	// stream is sent instead of body; it can only be read once, so a
+	// streamed request is never retried
+	stream      io.Reader
+	contentType string
+	timeout     time.Duration
+	// once disables retries
+	once bool
+	// noReplay marks a request that must not be applied twice: it is only
+	// retried when the connection was refused, so Typesense never saw it
+	noReplay bool
+}
+
+// do sends the request, retrying transient failures, and returns the
+// response if Typesense answered 2xx. Any other status is returned as an
+// *Error. Every call goes to the leader, reads included: exports and
+// counts feed offload verification, so they must not come from a lagging
+// follower. A node that fails is marked down, so the next attempt goes to
+// the node that takes over. The request timeout stays in force until the
+// response body is closed.
+func (c *Client) do(ctx context.Context, r request) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if r.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+	}
+
+	attempts := max(c.Retry.Attempts, 1)
+	if r.once || r.stream != nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		node := c.Cluster.Targets(true)[0]
+		resp, err := c.send(ctx, node, r)
+		if err == nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		var wait time.Duration
+		if err == nil {
+			wait = retryAfter(resp)
+			err = responseError(r.op, resp)
+		}
+		// Running out of the caller's or the request's own time says
+		// nothing about the node
+		if ctx.Err() == nil && nodeFailed(err) {
+			// Let the next attempt go to another node
+			c.Cluster.MarkDown(node)
+		}
+		if attempt >= attempts || !retryable(err) || (r.noReplay && !errors.Is(err, syscall.ECONNREFUSED)) {
+			cancel()
+			return nil, err
+		}
+
+		wait = max(wait, c.Retry.backoff(attempt))
+		log.Printf("typesense %s failed attempt=%d/%d retry_in=%s err=%v", r.op, attempt, attempts, wait.Round(time.Millisecond), err)
+		select {
+		case <-ctx.Done():
+			cancel()
+			return nil, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, node *url.URL, r request) (*http.Response, error) {
+	var body io.Reader
+	switch {
+	case r.stream != nil:
+		body = r.stream
+	case r.body != nil:
+		body = bytes.NewReader(r.body)
+	}
+
+	target := strings.TrimSuffix(node.String(), "/") + r.path
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-TYPESENSE-API-KEY", c.APIKey)
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	return c.Client.Do(req)
+}
+
+// retryable reports whether err is worth another attempt: Typesense
+// overloaded or failing, or not accepting connections yet.
+func retryable(err error) bool {
+	var te *Error
+	if errors.As(err, &te) {
+		return te.retryable()
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// nodeFailed reports whether err means the node itself is in trouble: it
+// refused the connection, the transport timed out or it failed with a 5xx.
+// A 429 says nothing about the node's health. Callers must rule out
+// errors caused by their own context ending first.
+func nodeFailed(err error) bool {
+	var te *Error
+	if errors.As(err, &te) {
+		return te.Status >= 500
+	}
+	var ne net.Error
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		(errors.As(err, &ne) && ne.Timeout())
+}
+
+// backoff is the delay before the attempt after the given one: BaseDelay
+// doubled per attempt, capped at MaxDelay, with full jitter.
+func (r Retry) backoff(attempt int) time.Duration {
+	d := r.BaseDelay << (attempt - 1)
+	if d <= 0 || (r.MaxDelay > 0 && d > r.MaxDelay) {
+		d = r.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d) + 1
+}
+
+// retryAfter honours a Retry-After header given in seconds.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// cancelOnClose releases the request's timeout once the body is done with.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func (c *Client) getJSON(ctx context.Context, op, path string, out any) error {
+	resp, err := c.do(ctx, request{op: op, method: "GET", path: path, timeout: c.Timeouts.Request})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) sendJSON(ctx context.Context, op, method, path string, body []byte) error {
+	resp, err := c.do(ctx, request{
+		op:          op,
+		method:      method,
+		path:        path,
+		body:        body,
+		contentType: "application/json",
+		timeout:     c.Timeouts.Request,
+	})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -1,28 +1,22 @@
 package typesense
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 )
 
-func (c *Client) GetSchema(collection string) ([]byte, error) {
-	req, _ := http.NewRequest(
-		"GET",
-		fmt.Sprintf("%s/collections/%s", c.BaseURL, collection),
-		nil,
-	)
-	req.Header.Set("X-TYPESENSE-API-KEY", c.APIKey)
-
-	resp, err := c.Client.Do(req)
+func (c *Client) GetSchema(ctx context.Context, collection string) ([]byte, error) {
+	resp, err := c.do(ctx, request{
+		op:      "get schema",
+		method:  "GET",
+		path:    "/collections/" + collection,
+		timeout: c.Timeouts.Request,
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to fetch schema: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("failed to fetch schema")
-	}
-
 	return io.ReadAll(resp.Body)
 }
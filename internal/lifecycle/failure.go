@@ -0,0 +1,103 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+)
+
+// ErrNotFailed is returned by Retry and Reset for collections that are not FAILED.
+var ErrNotFailed = errors.New("collection is not in FAILED state")
+
+// Retry runs the failed operation again from the state it started in.
+func (m *Manager) Retry(ctx context.Context, collection string) error {
+	ctx, done, err := m.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if m.stateStore.Get(collection) != state.Failed {
+		return ErrNotFailed
+	}
+
+	failure := m.stateStore.GetFailure(collection)
+	if failure == nil {
+		return fmt.Errorf("no failure recorded for %s, use reset instead", collection)
+	}
+	log.Printf("lifecycle retry collection=%s op=%s attempts=%d", collection, failure.Op, failure.Attempts)
+
+	switch failure.Op {
+	case OpReload:
+		if err := m.stateStore.Transition(collection, state.Failed, state.Cold); err != nil {
+			return err
+		}
+		_, err := m.reload(ctx, collection)
+		return err
+	case OpOffload:
+		if err := m.stateStore.Transition(collection, state.Failed, state.Draining); err != nil {
+			return err
+		}
+		return m.offload(ctx, collection)
+	default:
+		return fmt.Errorf("cannot retry unknown operation %q", failure.Op)
+	}
+}
+
+// Reset clears a failure without retrying it. The collection becomes HOT if
+// the engine still has it and COLD if only its snapshot is left. After a
+// failed reload the engine copy may be partial, so it is deleted and the
+// collection goes back to COLD whenever a snapshot exists; offloading the
+// partial copy would overwrite the only good snapshot.
+func (m *Manager) Reset(ctx context.Context, collection string) (state.State, error) {
+	if m.stateStore.Get(collection) != state.Failed {
+		return "", ErrNotFailed
+	}
+
+	// Another instance of a pool being down does not matter, as long as
+	// it is not the one the collection is on
+	collections, err := m.engine.ListCollections(ctx)
+	if engine.Unknown(err, m.stateStore.Placement(collection)) {
+		return "", err
+	}
+	inEngine := slices.Contains(collections, collection)
+
+	hasSnapshot, err := m.snapshots.Exists(collection)
+	if err != nil {
+		return "", err
+	}
+
+	failure := m.stateStore.GetFailure(collection)
+	reloadFailed := failure != nil && failure.Op == OpReload
+
+	var next state.State
+	switch {
+	case reloadFailed && hasSnapshot:
+		if inEngine {
+			err := m.engine.Delete(ctx, collection)
+			if err != nil && !errors.Is(err, engine.ErrNotFound) {
+				return "", fmt.Errorf("delete partial reload of %s: %w", collection, err)
+			}
+			log.Printf("lifecycle reset collection=%s deleted partial reload from engine", collection)
+		}
+		next = state.Cold
+	case inEngine:
+		next = state.Hot
+	case hasSnapshot:
+		next = state.Cold
+	default:
+		return "", fmt.Errorf("%s: %w", collection, errMissingEverywhere)
+	}
+
+	if err := m.stateStore.Transition(collection, state.Failed, next); err != nil {
+		return "", err
+	}
+	m.stateStore.ClearFailure(collection)
+	log.Printf("lifecycle reset collection=%s state=%s", collection, next)
+	return next, nil
+}
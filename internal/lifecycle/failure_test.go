@@ -0,0 +1,237 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
+)
+
+// fakeEngine holds collection names only; document calls are not needed
+// by the tests that use it.
+type fakeEngine struct {
+	collections []string
+	deleted     []string
+	// listErr is returned with the collections by ListCollections
+	listErr error
+}
+
+func (e *fakeEngine) GetSchema(ctx context.Context, collection string) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (e *fakeEngine) Export(ctx context.Context, collection string) (io.ReadCloser, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (e *fakeEngine) CreateCollection(ctx context.Context, schema []byte) error {
+	return errors.ErrUnsupported
+}
+
+func (e *fakeEngine) ImportDocuments(ctx context.Context, collection string, r io.Reader) (*engine.ImportResult, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (e *fakeEngine) Delete(ctx context.Context, collection string) error {
+	i := slices.Index(e.collections, collection)
+	if i < 0 {
+		return engine.ErrNotFound
+	}
+	e.collections = slices.Delete(e.collections, i, i+1)
+	e.deleted = append(e.deleted, collection)
+	return nil
+}
+
+func (e *fakeEngine) DocumentCount(ctx context.Context, collection string) (int64, error) {
+	return 0, nil
+}
+
+func (e *fakeEngine) ListCollections(ctx context.Context) ([]string, error) {
+	return slices.Clone(e.collections), e.listErr
+}
+
+func (e *fakeEngine) Health(ctx context.Context) error { return nil }
+
+func (e *fakeEngine) Info(ctx context.Context) (engine.Info, error) {
+	return engine.Info{Name: "fake"}, nil
+}
+
+func (e *fakeEngine) CollectionFromPath(path string) string { return "" }
+
+func newTestManager(t *testing.T, eng engine.Engine) (*Manager, *state.Store, snapshot.Store) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := state.NewSQLite(filepath.Join(dir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshots := snapshot.NewLocal(filepath.Join(dir, "snapshots"))
+	return New(eng, snapshots, snapshot.CodecNone, store, 1, 0), store, snapshots
+}
+
+func writeSnapshot(t *testing.T, snapshots snapshot.Store, collection string) {
+	t.Helper()
+	w, err := snapshot.Begin(snapshots, collection, snapshot.Source{Engine: "fake"}, snapshot.CodecNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SaveSchema([]byte(`{"name":"` + collection + `"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SaveDocuments(strings.NewReader("{\"id\":\"1\"}\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fail puts the collection in FAILED through the state the operation
+// started from.
+func fail(t *testing.T, store *state.Store, collection, op string) {
+	t.Helper()
+	from, via := state.Cold, state.Loading
+	if op == OpOffload {
+		from, via = state.Hot, state.Draining
+	}
+	if _, err := store.Register(collection, from); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Transition(collection, from, via); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Fail(collection, via, op, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResetAfterFailedReloadDeletesPartialCopy(t *testing.T) {
+	eng := &fakeEngine{collections: []string{"products"}}
+	m, store, snapshots := newTestManager(t, eng)
+	writeSnapshot(t, snapshots, "products")
+	fail(t, store, "products", OpReload)
+
+	next, err := m.Reset(context.Background(), "products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != state.Cold {
+		t.Errorf("Reset state = %s, want %s", next, state.Cold)
+	}
+	if got := store.Get("products"); got != state.Cold {
+		t.Errorf("stored state = %s, want %s", got, state.Cold)
+	}
+	if !slices.Equal(eng.deleted, []string{"products"}) {
+		t.Errorf("deleted = %v, want the partial copy deleted", eng.deleted)
+	}
+	if store.GetFailure("products") != nil {
+		t.Error("failure not cleared")
+	}
+	if ok, _ := snapshots.Exists("products"); !ok {
+		t.Error("snapshot removed")
+	}
+}
+
+func TestResetAfterFailedReloadWithoutSnapshotKeepsEngineCopy(t *testing.T) {
+	eng := &fakeEngine{collections: []string{"products"}}
+	m, store, _ := newTestManager(t, eng)
+	fail(t, store, "products", OpReload)
+
+	next, err := m.Reset(context.Background(), "products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != state.Hot {
+		t.Errorf("Reset state = %s, want %s", next, state.Hot)
+	}
+	if len(eng.deleted) != 0 {
+		t.Errorf("deleted = %v, want the only copy kept", eng.deleted)
+	}
+}
+
+func TestResetAfterFailedOffload(t *testing.T) {
+	tests := []struct {
+		name     string
+		inEngine bool
+		want     state.State
+	}{
+		{"still in engine", true, state.Hot},
+		{"deleted from engine", false, state.Cold},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng := &fakeEngine{}
+			if tt.inEngine {
+				eng.collections = []string{"products"}
+			}
+			m, store, snapshots := newTestManager(t, eng)
+			writeSnapshot(t, snapshots, "products")
+			fail(t, store, "products", OpOffload)
+
+			next, err := m.Reset(context.Background(), "products")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next != tt.want {
+				t.Errorf("Reset state = %s, want %s", next, tt.want)
+			}
+			if len(eng.deleted) != 0 {
+				t.Errorf("deleted = %v, want nothing deleted", eng.deleted)
+			}
+		})
+	}
+}
+
+func TestResetRequiresFailed(t *testing.T) {
+	m, store, _ := newTestManager(t, &fakeEngine{})
+	if _, err := store.Register("products", state.Hot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Reset(context.Background(), "products"); !errors.Is(err, ErrNotFailed) {
+		t.Errorf("Reset error = %v, want %v", err, ErrNotFailed)
+	}
+}
+
+func TestResetWithUnreachableInstance(t *testing.T) {
+	partial := &engine.PartialError{Unreachable: []string{"ts-2"}, Err: errors.New("connection refused")}
+	tests := []struct {
+		name     string
+		instance string
+		wantErr  bool
+	}{
+		{"on a reachable instance", "ts-1", false},
+		{"on the unreachable instance", "ts-2", true},
+		{"not placed", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eng := &fakeEngine{collections: []string{"products"}, listErr: partial}
+			m, store, _ := newTestManager(t, eng)
+			fail(t, store, "products", OpOffload)
+			if err := store.SetPlacement("products", tt.instance); err != nil {
+				t.Fatal(err)
+			}
+
+			next, err := m.Reset(context.Background(), "products")
+			if tt.wantErr {
+				if !errors.Is(err, partial.Err) {
+					t.Errorf("Reset error = %v, want the listing error", err)
+				}
+				if got := store.Get("products"); got != state.Failed {
+					t.Errorf("stored state = %s, want %s", got, state.Failed)
+				}
+				return
+			}
+			if err != nil || next != state.Hot {
+				t.Errorf("Reset = %s, %v, want %s", next, err, state.Hot)
+			}
+		})
+	}
+}
@@ -1,27 +1,57 @@
 package lifecycle
 
 import (
-	"github.com/SoyebSarkar/Hiberstack/internal/engine/typesense"
+	"context"
+	"sync"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
 	"github.com/SoyebSarkar/Hiberstack/internal/state"
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
 )
 
 type Manager struct {
-	ts          *typesense.Client
-	snapshotDir string
-	stateStore  *state.Store
-	reloadSem   chan struct{}
+	engine     engine.Engine
+	snapshots  snapshot.Store
+	codec      snapshot.Codec
+	stateStore *state.Store
+	reloadSem  *semaphore
+
+	// importFailureThreshold is the share of documents allowed to fail
+	// during a reload before the reload itself is considered failed.
+	importFailureThreshold float64
+
+	// mu guards closing; running counts the reloads and offloads that
+	// Shutdown has to wait for, and abort cancels them
+	mu       sync.Mutex
+	closing  bool
+	running  sync.WaitGroup
+	aborted  context.Context
+	abortAll context.CancelFunc
 }
 
 func New(
-	ts *typesense.Client,
-	snapshotDir string,
+	engine engine.Engine,
+	snapshots snapshot.Store,
+	codec snapshot.Codec,
 	stateStore *state.Store,
 	maxConcurrentReloads int,
+	importFailureThreshold float64,
 ) *Manager {
+	aborted, abortAll := context.WithCancel(context.Background())
 	return &Manager{
-		ts:          ts,
-		snapshotDir: snapshotDir,
-		stateStore:  stateStore,
-		reloadSem:   make(chan struct{}, maxConcurrentReloads),
+		engine:                 engine,
+		snapshots:              snapshots,
+		codec:                  codec,
+		stateStore:             stateStore,
+		reloadSem:              newSemaphore(maxConcurrentReloads),
+		importFailureThreshold: importFailureThreshold,
+		aborted:                aborted,
+		abortAll:               abortAll,
 	}
 }
+
+// SetMaxConcurrentReloads changes how many reloads may run at once. Reloads
+// already running are not interrupted.
+func (m *Manager) SetMaxConcurrentReloads(n int) {
+	m.reloadSem.setLimit(n)
+}
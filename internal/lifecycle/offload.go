@@ -1,47 +1,168 @@
 package lifecycle
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"path/filepath"
 
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
 	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
 	"github.com/SoyebSarkar/Hiberstack/internal/state"
 	"github.com/SoyebSarkar/Hiberstack/snapshot"
 )
 
-func (m *Manager) Offload(collection string) error {
+// ErrVerification is returned when an offload is aborted because the
+// snapshot could not be shown to hold the whole collection.
+var ErrVerification = errors.New("snapshot verification failed")
+
+// Offload snapshots a DRAINING collection and deletes it from the engine.
+// The collection is only deleted once the committed snapshot has been read
+// back and matches the engine's document count; any failure before that
+// point, including cancellation through ctx or by Shutdown, returns the
+// collection to HOT untouched.
+func (m *Manager) Offload(ctx context.Context, collection string) error {
+	ctx, done, err := m.begin(ctx)
+	if err != nil {
+		if m.stateStore.Get(collection) == state.Draining {
+			m.revertDrain(collection)
+		}
+		return err
+	}
+	defer done()
+	return m.offload(ctx, collection)
+}
+
+func (m *Manager) offload(ctx context.Context, collection string) error {
 	st := m.stateStore.Get(collection)
 	if st != state.Draining {
 		return nil
 	}
 	log.Printf("lifecycle offload start collection=%s", collection)
-	baseDir := filepath.Join(m.snapshotDir, collection)
 
-	schema, err := m.ts.GetSchema(collection)
+	if err := m.snapshotAndVerify(ctx, collection); err != nil {
+		log.Printf("lifecycle offload aborted collection=%s err=%v", collection, err)
+		if errors.Is(err, ErrVerification) {
+			metrics.OffloadVerificationFailedTotal.Inc()
+		}
+		m.revertDrain(collection)
+		return err
+	}
+
+	// Past this point the offload has to complete, so this is the last
+	// chance to back out
+	if err := ctx.Err(); err != nil {
+		log.Printf("lifecycle offload aborted collection=%s err=%v", collection, err)
+		m.revertDrain(collection)
+		return err
+	}
+
+	err := m.engine.Delete(context.WithoutCancel(ctx), collection)
+	if errors.Is(err, engine.ErrNotFound) {
+		// Already gone, e.g. a retried delete that had gone through
+		log.Printf("lifecycle offload collection=%s already deleted from engine", collection)
+		err = nil
+	}
 	if err != nil {
+		// The snapshot is safe but the collection may still be loaded;
+		// an operator has to decide which side is authoritative
+		log.Printf("lifecycle offload delete failed collection=%s err=%v", collection, err)
+		if err := m.stateStore.Fail(collection, state.Draining, OpOffload, err); err != nil {
+			log.Printf("lifecycle offload collection=%s unable to record failure: %v", collection, err)
+		}
 		return err
 	}
 
-	if err := snapshot.SaveSchema(baseDir, schema); err != nil {
+	if err := m.stateStore.Transition(collection, state.Draining, state.Cold); err != nil {
 		return err
 	}
+	m.stateStore.ClearFailure(collection)
+	metrics.OffloadTotal.Inc()
+
+	return nil
+}
+
+// revertDrain returns a DRAINING collection to HOT when its offload is
+// abandoned before the engine copy was deleted.
+func (m *Manager) revertDrain(collection string) {
+	if err := m.stateStore.Transition(collection, state.Draining, state.Hot); err != nil {
+		log.Printf("lifecycle offload collection=%s unable to revert to HOT: %v", collection, err)
+	}
+}
 
-	docs, err := m.ts.Export(collection)
+func (m *Manager) snapshotAndVerify(ctx context.Context, collection string) error {
+	info, err := m.engine.Info(ctx)
 	if err != nil {
 		return err
 	}
-	defer docs.Close()
 
-	if err := snapshot.SaveDocuments(baseDir, docs); err != nil {
+	w, err := snapshot.Begin(m.snapshots, collection, snapshot.Source{
+		Engine:        info.Name,
+		EngineVersion: info.Version,
+	}, m.codec)
+	if err != nil {
 		return err
 	}
 
-	if err := m.ts.Delete(collection); err != nil {
+	if err := m.writeSnapshot(ctx, collection, w); err != nil {
+		w.Abort()
 		return err
 	}
 
-	m.stateStore.Set(collection, state.Cold)
-	metrics.OffloadTotal.Inc()
+	// Writes are blocked while draining, so the count cannot move under us
+	expected, err := m.engine.DocumentCount(ctx, collection)
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	if w.DocumentCount() != expected {
+		w.Abort()
+		return fmt.Errorf("%w: exported %d documents, collection has %d", ErrVerification, w.DocumentCount(), expected)
+	}
 
+	if err := w.Commit(); err != nil {
+		w.Abort()
+		return err
+	}
+
+	// Read the committed snapshot back from the store
+	manifest, err := snapshot.Verify(m.snapshots, collection)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	if manifest == nil || manifest.DocumentCount != expected {
+		return fmt.Errorf("%w: stored manifest does not match export", ErrVerification)
+	}
+
+	log.Printf("lifecycle offload verified collection=%s documents=%d", collection, expected)
 	return nil
 }
+
+func (m *Manager) writeSnapshot(ctx context.Context, collection string, w *snapshot.Writer) error {
+	schema, err := m.engine.GetSchema(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	if err := w.SaveSchema(schema); err != nil {
+		return err
+	}
+
+	if extras, ok := m.engine.(engine.Extras); ok {
+		data, err := extras.ExportExtras(ctx, collection)
+		if err != nil {
+			return err
+		}
+		if err := w.SaveExtras(data); err != nil {
+			return err
+		}
+	}
+
+	docs, err := m.engine.Export(ctx, collection)
+	if err != nil {
+		return err
+	}
+	defer docs.Close()
+
+	return w.SaveDocuments(docs)
+}
@@ -0,0 +1,126 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
+)
+
+var errMissingEverywhere = errors.New("collection exists neither in the engine nor as a snapshot")
+
+// RecoverInterrupted resolves collections left LOADING or DRAINING by a
+// restart. It must run before the proxy and scheduler start. Interrupted
+// reloads are rolled back to COLD; interrupted drains are finished in the
+// background, since an offload can take a while. Collections on engine
+// instances that cannot be reached are skipped.
+func (m *Manager) RecoverInterrupted(ctx context.Context) error {
+	collections, listErr := m.engine.ListCollections(ctx)
+	var partial *engine.PartialError
+	if listErr != nil && !errors.As(listErr, &partial) {
+		return listErr
+	}
+
+	loading, err := m.stateStore.ListByState(state.Loading)
+	if err != nil {
+		return err
+	}
+	for _, c := range loading {
+		if engine.Unknown(listErr, m.stateStore.Placement(c)) {
+			log.Printf("recovery collection=%s state=LOADING decision=skip err=%v", c, listErr)
+			continue
+		}
+		m.recoverReload(ctx, c, slices.Contains(collections, c))
+	}
+
+	draining, err := m.stateStore.ListByState(state.Draining)
+	if err != nil {
+		return err
+	}
+	for _, c := range draining {
+		if engine.Unknown(listErr, m.stateStore.Placement(c)) {
+			log.Printf("recovery collection=%s state=DRAINING decision=skip err=%v", c, listErr)
+			continue
+		}
+		m.recoverOffload(ctx, c, slices.Contains(collections, c))
+	}
+	return nil
+}
+
+func (m *Manager) recoverReload(ctx context.Context, collection string, inEngine bool) {
+	hasSnapshot, err := m.snapshots.Exists(collection)
+	if err != nil {
+		log.Printf("recovery collection=%s state=LOADING decision=skip err=%v", collection, err)
+		return
+	}
+
+	switch {
+	case hasSnapshot && inEngine:
+		// The import may be partial; the snapshot is the source of truth
+		log.Printf("recovery collection=%s state=LOADING decision=rollback reason=interrupted_import", collection)
+		if err := m.engine.Delete(ctx, collection); err != nil && !errors.Is(err, engine.ErrNotFound) {
+			log.Printf("recovery collection=%s delete failed: %v", collection, err)
+			m.recoveryFailed(collection, state.Loading, OpReload, err)
+			return
+		}
+		m.recoveryTransition(collection, state.Loading, state.Cold)
+
+	case hasSnapshot:
+		log.Printf("recovery collection=%s state=LOADING decision=rollback reason=not_in_engine", collection)
+		m.recoveryTransition(collection, state.Loading, state.Cold)
+
+	case inEngine:
+		// Without a snapshot the engine holds the only copy; keep it
+		log.Printf("recovery collection=%s state=LOADING decision=keep reason=no_snapshot", collection)
+		m.recoveryTransition(collection, state.Loading, state.Hot)
+
+	default:
+		log.Printf("recovery collection=%s state=LOADING decision=fail reason=missing_everywhere", collection)
+		m.recoveryFailed(collection, state.Loading, OpReload, errMissingEverywhere)
+	}
+}
+
+func (m *Manager) recoverOffload(ctx context.Context, collection string, inEngine bool) {
+	if inEngine {
+		// Writes stay blocked while DRAINING, so the drain can simply resume
+		log.Printf("recovery collection=%s state=DRAINING decision=resume_offload", collection)
+		go func() {
+			// Outlives startup; Shutdown decides how long it may run
+			if err := m.Offload(context.WithoutCancel(ctx), collection); err != nil {
+				log.Printf("recovery collection=%s resumed offload failed: %v", collection, err)
+			}
+		}()
+		return
+	}
+
+	// The collection was deleted, so the crash hit between delete and the
+	// state update. Only trust the snapshot if it still verifies.
+	manifest, err := snapshot.Verify(m.snapshots, collection)
+	if err != nil {
+		log.Printf("recovery collection=%s state=DRAINING decision=fail reason=snapshot_unusable err=%v", collection, err)
+		m.recoveryFailed(collection, state.Draining, OpOffload, fmt.Errorf("collection deleted but snapshot unusable: %w", err))
+		return
+	}
+	if manifest == nil {
+		log.Printf("recovery collection=%s state=DRAINING snapshot has no manifest, trusting it", collection)
+	}
+	log.Printf("recovery collection=%s state=DRAINING decision=complete_offload reason=not_in_engine", collection)
+	m.recoveryTransition(collection, state.Draining, state.Cold)
+}
+
+func (m *Manager) recoveryTransition(collection string, from, to state.State) {
+	if err := m.stateStore.Transition(collection, from, to); err != nil {
+		log.Printf("recovery collection=%s transition failed: %v", collection, err)
+	}
+}
+
+func (m *Manager) recoveryFailed(collection string, from state.State, op string, cause error) {
+	if err := m.stateStore.Fail(collection, from, op, cause); err != nil {
+		log.Printf("recovery collection=%s unable to record failure: %v", collection, err)
+	}
+}
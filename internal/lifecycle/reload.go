@@ -1,52 +1,155 @@
 package lifecycle
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"os"
-	"path/filepath"
 	"time"
 
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
 	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
 	"github.com/SoyebSarkar/Hiberstack/internal/state"
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
 )
 
-func (m *Manager) Reload(collection string) error {
+const (
+	OpReload  = "reload"
+	OpOffload = "offload"
+)
+
+// Reload restores a COLD collection from its snapshot. Any failure rolls
+// back a partially created collection and moves the collection to FAILED,
+// so it never stays LOADING. A reload cancelled through ctx or by Shutdown
+// is rolled back to COLD instead.
+//
+// reloaded reports whether this call loaded the collection; it is false
+// without an error if the collection was not COLD or another caller
+// reloaded it first.
+func (m *Manager) Reload(ctx context.Context, collection string) (reloaded bool, err error) {
+	ctx, done, err := m.begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer done()
+	return m.reload(ctx, collection)
+}
+
+func (m *Manager) reload(ctx context.Context, collection string) (bool, error) {
 	st := m.stateStore.Get(collection)
 	if st != state.Cold {
-		return nil
+		return false, nil
+	}
+	if err := m.reloadSem.acquire(ctx); err != nil {
+		return false, err
+	}
+	defer m.reloadSem.release()
+	// Another caller may have won the race while we waited for a slot
+	if err := m.stateStore.Transition(collection, state.Cold, state.Loading); err != nil {
+		log.Printf("lifecycle reload skipped collection=%s err=%v", collection, err)
+		return false, nil
 	}
-	m.reloadSem <- struct{}{}
-	defer func() {
-		<-m.reloadSem
-	}()
 	start := time.Now()
 	log.Printf("lifecycle reload start collection=%s", collection)
-	m.stateStore.Set(collection, state.Loading)
 
-	baseDir := filepath.Join(m.snapshotDir, collection)
+	created, err := m.restore(ctx, collection)
+	if err != nil {
+		log.Printf("lifecycle reload failed collection=%s err=%v", collection, err)
+		if created {
+			// Leave no partially imported collection behind, even when
+			// the reload itself was cancelled
+			err := m.engine.Delete(context.WithoutCancel(ctx), collection)
+			if err != nil && !errors.Is(err, engine.ErrNotFound) {
+				log.Printf("lifecycle reload cleanup failed collection=%s err=%v", collection, err)
+			}
+		}
+		if ctx.Err() != nil {
+			// Nothing is wrong with the snapshot; it can be reloaded later
+			log.Printf("lifecycle reload aborted collection=%s", collection)
+			if err := m.stateStore.Transition(collection, state.Loading, state.Cold); err != nil {
+				log.Printf("lifecycle reload collection=%s unable to revert to COLD: %v", collection, err)
+			}
+			return false, err
+		}
+		if err := m.stateStore.Fail(collection, state.Loading, OpReload, err); err != nil {
+			log.Printf("lifecycle reload collection=%s unable to record failure: %v", collection, err)
+		}
+		metrics.ReloadFailedTotal.Inc()
+		return false, err
+	}
+
+	if err := m.stateStore.Transition(collection, state.Loading, state.Hot); err != nil {
+		return false, err
+	}
+	m.stateStore.ClearFailure(collection)
+	log.Printf("lifecycle reload complete collection=%s duration=%s", collection, time.Since(start))
+	metrics.ReloadTotal.Inc()
+	metrics.ReloadDuration.Observe(time.Since(start).Seconds())
+	return true, nil
+}
+
+// restore loads the snapshot into the engine. created reports whether the
+// collection was created, and so has to be removed if restore failed.
+func (m *Manager) restore(ctx context.Context, collection string) (created bool, err error) {
+	// Every file comes from the snapshot opened here and is verified
+	// against its manifest as it is read
+	snap, err := snapshot.OpenReader(m.snapshots, collection)
+	if err != nil {
+		return false, err
+	}
+	defer snap.Close()
+	if snap.Manifest() == nil {
+		log.Printf("lifecycle reload collection=%s snapshot has no manifest, skipping verification", collection)
+	}
 
-	schema, err := os.ReadFile(filepath.Join(baseDir, "schema.json"))
+	schema, err := snap.Schema()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if err := m.ts.CreateCollection(schema); err != nil {
-		return err
+	if err := m.engine.CreateCollection(ctx, schema); err != nil {
+		// Whatever holds the name is not ours to remove on rollback
+		if errors.Is(err, engine.ErrAlreadyExists) {
+			return false, fmt.Errorf("collection %s already exists in the engine while COLD: %w", collection, err)
+		}
+		// Any other failure may have come after the engine created the
+		// collection, e.g. a timeout; the rollback delete is harmless if not
+		return true, err
 	}
 
-	file, err := os.Open(filepath.Join(baseDir, "documents.jsonl"))
+	file, err := snap.Documents()
+	if err != nil {
+		return true, err
+	}
+	result, err := m.engine.ImportDocuments(ctx, collection, file)
+	// Close reports a mismatch with the manifest even if the engine did
+	// not pass the failed read on
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		return err
+		return true, err
 	}
-	defer file.Close()
 
-	if err := m.ts.ImportDocuments(collection, file); err != nil {
-		return err
+	if result.Failed > 0 {
+		metrics.ImportFailedDocuments.Add(float64(result.Failed))
+
+		if result.FailureRatio() > m.importFailureThreshold {
+			return true, fmt.Errorf("import of %s exceeded failure threshold: %s", collection, result)
+		}
+
+		log.Printf("lifecycle reload partial import collection=%s %s", collection, result)
 	}
 
-	m.stateStore.Set(collection, state.Hot)
-	log.Printf("lifecycle reload complete collection=%s duration=%s", collection, time.Since(start))
-	metrics.ReloadTotal.Inc()
-	metrics.ReloadDuration.Observe(time.Since(start).Seconds())
-	return nil
+	if extras, ok := m.engine.(engine.Extras); ok {
+		data, err := snap.Extras()
+		if err != nil {
+			return true, err
+		}
+		if err := extras.RestoreExtras(ctx, collection, data); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
 }
@@ -0,0 +1,58 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+// semaphore bounds concurrent reloads. Unlike a buffered channel its limit
+// can be changed while reloads are running; lowering it lets running
+// reloads finish and only holds back new ones.
+type semaphore struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newSemaphore(limit int) *semaphore {
+	s := &semaphore{limit: limit}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// acquire waits for a free slot, giving up when ctx is done.
+func (s *semaphore) acquire(ctx context.Context) error {
+	// Wake the waiters up so they notice ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.cond.Broadcast()
+	})
+	defer stop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.active >= s.limit {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		s.cond.Wait()
+	}
+	s.active++
+	return nil
+}
+
+func (s *semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	s.cond.Broadcast()
+}
+
+func (s *semaphore) setLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.cond.Broadcast()
+}
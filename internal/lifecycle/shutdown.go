@@ -0,0 +1,65 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// ErrShuttingDown is returned for reloads and offloads requested once
+// Shutdown has begun.
+var ErrShuttingDown = errors.New("lifecycle manager is shutting down")
+
+// begin registers a reload or offload with the manager so Shutdown can wait
+// for it. The returned context is also cancelled if Shutdown gives up
+// waiting; done must be called when the operation returns.
+func (m *Manager) begin(ctx context.Context) (context.Context, func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closing {
+		return nil, nil, ErrShuttingDown
+	}
+	m.running.Add(1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(m.aborted, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		m.running.Done()
+	}, nil
+}
+
+// Close stops new reloads and offloads from starting; they fail with
+// ErrShuttingDown. Running ones are left alone until Shutdown.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.closing = true
+	m.mu.Unlock()
+}
+
+// Shutdown stops new reloads and offloads from starting and waits for the
+// running ones to finish. If ctx is done first, the running operations are
+// cancelled, which rolls reloads back to COLD and offloads that have not
+// deleted the collection yet back to HOT; Shutdown then waits for those
+// rollbacks and returns ctx's error.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.Close()
+
+	finished := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+	}
+
+	log.Printf("lifecycle shutdown deadline reached, aborting running reloads and offloads")
+	m.abortAll()
+	<-finished
+	return ctx.Err()
+}
@@ -15,16 +15,71 @@ var (
 		Help: "Total number of collection reloads",
 	})
 
+	ReloadFailedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "hiberstack_reload_failed_total",
+		Help: "Total number of collection reloads that failed",
+	})
+
 	OffloadTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "hiberstack_offload_total",
 		Help: "Total number of collection offloads",
 	})
 
+	OffloadVerificationFailedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "hiberstack_offload_verification_failed_total",
+		Help: "Total number of offloads aborted because the snapshot failed verification",
+	})
+
 	WriteBlockedTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "hiberstack_write_blocked_total",
 		Help: "Total number of write requests blocked during draining",
 	})
 
+	ImportFailedDocuments = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "hiberstack_import_failed_documents_total",
+		Help: "Total number of documents rejected by the engine during reloads",
+	})
+
+	MemoryEvictionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "hiberstack_memory_evictions_total",
+		Help: "Total number of collections offloaded because of memory pressure",
+	})
+
+	PrewarmTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "hiberstack_prewarm_total",
+		Help: "Total number of collections reloaded ahead of time by a prewarm schedule",
+	})
+
+	PredictivePrewarmTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "hiberstack_predictive_prewarm_total",
+		Help: "Total number of collections reloaded ahead of predicted traffic",
+	})
+
+	PredictivePrewarmOutcomes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hiberstack_predictive_prewarm_outcomes_total",
+		Help: "Predictive prewarms by whether traffic followed within the protection window",
+	}, []string{"outcome"})
+
+	ReconcileFixesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hiberstack_reconcile_fixes_total",
+		Help: "Total number of state corrections made by the reconciler",
+	}, []string{"action"})
+
+	ProxyFailoverTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "hiberstack_proxy_failover_total",
+		Help: "Total number of proxied reads retried on another engine node",
+	})
+
+	PlacementsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hiberstack_placements_total",
+		Help: "Total number of collections placed on each engine instance during reload",
+	}, []string{"instance"})
+
+	InstanceListFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hiberstack_instance_list_failures_total",
+		Help: "Total number of times an engine instance could not be listed and was left out",
+	}, []string{"instance"})
+
 	// -------- Gauges --------
 
 	CollectionsHot = promauto.NewGauge(prometheus.GaugeOpts{
@@ -47,6 +102,36 @@ var (
 		Help: "Number of LOADING collections",
 	})
 
+	CollectionsFailed = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "hiberstack_collections_failed",
+		Help: "Number of FAILED collections",
+	})
+
+	EngineMemoryUsed = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "hiberstack_engine_memory_used_bytes",
+		Help: "Memory used by the search engine, as last polled",
+	})
+
+	EngineMemoryBudget = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "hiberstack_engine_memory_budget_bytes",
+		Help: "Memory budget the eviction water marks are relative to",
+	})
+
+	StateDrift = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hiberstack_state_drift",
+		Help: "Collections whose state disagrees with the engine, by kind, as of the last reconcile",
+	}, []string{"kind"})
+
+	EngineNodeHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hiberstack_engine_node_healthy",
+		Help: "Whether an engine node passed its last health check (1) or not (0)",
+	}, []string{"node"})
+
+	EngineNodeLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hiberstack_engine_node_leader",
+		Help: "Whether an engine node was the cluster leader at its last health check",
+	}, []string{"node"})
+
 	// -------- Histograms --------
 
 	ReloadDuration = promauto.NewHistogram(prometheus.HistogramOpts{
@@ -69,4 +154,5 @@ func UpdateStateGauges(store *state.Store) {
 	CollectionsCold.Set(float64(counts[state.Cold]))
 	CollectionsDraining.Set(float64(counts[state.Draining]))
 	CollectionsLoading.Set(float64(counts[state.Loading]))
+	CollectionsFailed.Set(float64(counts[state.Failed]))
 }
@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// Duration is a time.Duration written as a string such as "90m". The
+// string "never" disables whatever the duration controls.
+type Duration struct {
+	time.Duration
+	Never bool
+}
+
+func (d Duration) String() string {
+	if d.Never {
+		return "never"
+	}
+	return d.Duration.String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"1h\" or \"never\"")
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(any) error) error {
+	// A *yaml.TypeError lets the decoder carry on and report other errors
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return &yaml.TypeError{Errors: []string{"duration must be a string like \"1h\" or \"never\""}}
+	}
+	if err := d.parse(s); err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("invalid duration %q: must be like \"1h\" or \"never\"", s)}}
+	}
+	return nil
+}
+
+func (d *Duration) parse(s string) error {
+	if s == "never" {
+		*d = Duration{Never: true}
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v <= 0 {
+		return fmt.Errorf("duration must be positive: %s", s)
+	}
+	*d = Duration{Duration: v}
+	return nil
+}
@@ -0,0 +1,261 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/cron"
+)
+
+const (
+	ReloadAsync    = "async"
+	ReloadBlocking = "blocking"
+)
+
+// Policy overrides lifecycle settings for collections whose name matches
+// either a glob (Match) or a regular expression (Regex). Unset fields fall
+// back to the global defaults.
+type Policy struct {
+	Name             string    `json:"name" yaml:"name"`
+	Match            string    `json:"match,omitempty" yaml:"match"`
+	Regex            string    `json:"regex,omitempty" yaml:"regex"`
+	OffloadAfter     *Duration `json:"offload_after,omitempty" yaml:"offload_after"`
+	DrainGracePeriod *Duration `json:"drain_grace_period,omitempty" yaml:"drain_grace_period"`
+	// Pinned collections are never offloaded.
+	Pinned     bool   `json:"pinned,omitempty" yaml:"pinned"`
+	ReloadMode string `json:"reload_mode,omitempty" yaml:"reload_mode"`
+	// Prewarm reloads COLD collections ahead of expected traffic, which
+	// then stay protected from offloading for PrewarmProtect.
+	Prewarm        []Prewarm `json:"prewarm,omitempty" yaml:"prewarm"`
+	PrewarmProtect *Duration `json:"prewarm_protect,omitempty" yaml:"prewarm_protect"`
+
+	re *regexp.Regexp
+}
+
+// Prewarm is a cron schedule, evaluated in Timezone (UTC if empty).
+type Prewarm struct {
+	Cron     string `json:"cron" yaml:"cron"`
+	Timezone string `json:"timezone,omitempty" yaml:"timezone"`
+
+	schedule *cron.Schedule
+	loc      *time.Location
+}
+
+// Next returns the first firing strictly after t.
+func (p Prewarm) Next(t time.Time) time.Time {
+	return p.schedule.Next(t.In(p.loc))
+}
+
+func (p *Prewarm) compile() error {
+	schedule, err := cron.Parse(p.Cron)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", p.Timezone, err)
+	}
+	p.schedule, p.loc = schedule, loc
+	return nil
+}
+
+// Defaults are the global settings applied when no policy matches.
+type Defaults struct {
+	OffloadAfter     time.Duration
+	DrainGracePeriod time.Duration
+	ReloadMode       string
+	PrewarmProtect   time.Duration
+}
+
+// Effective is the outcome of resolving the policies for one collection.
+type Effective struct {
+	Collection       string    `json:"collection"`
+	Policy           string    `json:"policy"`
+	Reason           string    `json:"reason"`
+	OffloadAfter     Duration  `json:"offload_after"`
+	DrainGracePeriod Duration  `json:"drain_grace_period"`
+	Pinned           bool      `json:"pinned"`
+	ReloadMode       string    `json:"reload_mode"`
+	Prewarm          []Prewarm `json:"prewarm,omitempty"`
+	PrewarmProtect   Duration  `json:"prewarm_protect"`
+}
+
+// Offloadable reports whether the idle scheduler may offload the collection.
+func (e Effective) Offloadable() bool {
+	return !e.Pinned && !e.OffloadAfter.Never
+}
+
+// Set is an ordered list of policies; the first match wins. A Set can be
+// replaced in place by Update, so everyone holding it sees new rules.
+type Set struct {
+	rules atomic.Pointer[rules]
+}
+
+type rules struct {
+	policies []Policy
+	defaults Defaults
+}
+
+type file struct {
+	Policies []Policy `json:"policies"`
+}
+
+// Load reads policies from a JSON file; they can also be given inline in
+// the YAML config. An empty path yields a Set that
+// only applies the defaults.
+func Load(filename string, defaults Defaults) (*Set, error) {
+	if filename == "" {
+		return New(nil, defaults)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	set, err := New(f.Policies, defaults)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return set, nil
+}
+
+// New validates the policies, reporting every problem at once.
+func New(policies []Policy, defaults Defaults) (*Set, error) {
+	var errs []error
+	compiled := make([]Policy, len(policies))
+
+	for i, p := range policies {
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("policy[%d]", i)
+		}
+
+		switch {
+		case p.Match != "" && p.Regex != "":
+			errs = append(errs, fmt.Errorf("%s: set either match or regex, not both", p.Name))
+		case p.Match != "":
+			if _, err := path.Match(p.Match, ""); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid glob %q: %w", p.Name, p.Match, err))
+			}
+		case p.Regex != "":
+			re, err := regexp.Compile(p.Regex)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid regex %q: %w", p.Name, p.Regex, err))
+			}
+			p.re = re
+		default:
+			errs = append(errs, fmt.Errorf("%s: match or regex is required", p.Name))
+		}
+
+		if p.DrainGracePeriod != nil && p.DrainGracePeriod.Never {
+			errs = append(errs, fmt.Errorf("%s: drain_grace_period cannot be never", p.Name))
+		}
+
+		switch p.ReloadMode {
+		case "", ReloadAsync, ReloadBlocking:
+		default:
+			errs = append(errs, fmt.Errorf("%s: invalid reload_mode %q", p.Name, p.ReloadMode))
+		}
+
+		if p.PrewarmProtect != nil && p.PrewarmProtect.Never {
+			errs = append(errs, fmt.Errorf("%s: prewarm_protect cannot be never", p.Name))
+		}
+		p.Prewarm = slices.Clone(p.Prewarm)
+		for j := range p.Prewarm {
+			if err := p.Prewarm[j].compile(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: prewarm[%d]: %w", p.Name, j, err))
+			}
+		}
+
+		compiled[i] = p
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	set := &Set{}
+	set.rules.Store(&rules{policies: compiled, defaults: defaults})
+	return set, nil
+}
+
+// Update atomically switches s to the policies and defaults of next.
+func (s *Set) Update(next *Set) {
+	s.rules.Store(next.rules.Load())
+}
+
+// Resolve returns the settings that apply to the collection and explains
+// where they came from.
+func (s *Set) Resolve(collection string) Effective {
+	r := s.rules.Load()
+	eff := Effective{
+		Collection:       collection,
+		Policy:           "default",
+		Reason:           "no policy matched",
+		OffloadAfter:     Duration{Duration: r.defaults.OffloadAfter},
+		DrainGracePeriod: Duration{Duration: r.defaults.DrainGracePeriod},
+		ReloadMode:       r.defaults.ReloadMode,
+		PrewarmProtect:   Duration{Duration: r.defaults.PrewarmProtect},
+	}
+
+	for _, p := range r.policies {
+		reason, ok := p.matches(collection)
+		if !ok {
+			continue
+		}
+
+		eff.Policy = p.Name
+		eff.Reason = reason
+		if p.OffloadAfter != nil {
+			eff.OffloadAfter = *p.OffloadAfter
+		}
+		if p.DrainGracePeriod != nil {
+			eff.DrainGracePeriod = *p.DrainGracePeriod
+		}
+		if p.ReloadMode != "" {
+			eff.ReloadMode = p.ReloadMode
+		}
+		if p.PrewarmProtect != nil {
+			eff.PrewarmProtect = *p.PrewarmProtect
+		}
+		eff.Pinned = p.Pinned
+		eff.Prewarm = p.Prewarm
+		break
+	}
+	return eff
+}
+
+// MinOffloadAfter is the shortest idle threshold of any policy or the
+// default. No collection can be offloaded before it has been idle this long.
+func (s *Set) MinOffloadAfter() time.Duration {
+	r := s.rules.Load()
+	min := r.defaults.OffloadAfter
+	for _, p := range r.policies {
+		if p.OffloadAfter != nil && !p.OffloadAfter.Never && p.OffloadAfter.Duration < min {
+			min = p.OffloadAfter.Duration
+		}
+	}
+	return min
+}
+
+func (p Policy) matches(collection string) (string, bool) {
+	if p.re != nil {
+		return fmt.Sprintf("matched regex %q", p.Regex), p.re.MatchString(collection)
+	}
+	ok, _ := path.Match(p.Match, collection)
+	return fmt.Sprintf("matched glob %q", p.Match), ok
+}
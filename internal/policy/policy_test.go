@@ -0,0 +1,110 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parsePolicies(t *testing.T, data string) []Policy {
+	t.Helper()
+	var policies []Policy
+	if err := json.Unmarshal([]byte(data), &policies); err != nil {
+		t.Fatal(err)
+	}
+	return policies
+}
+
+func TestResolve(t *testing.T) {
+	policies := parsePolicies(t, `[
+		{ "name": "logs",    "match": "tenant_*_logs", "offload_after": "1h" },
+		{ "name": "vip",     "regex": "^tenant_vip_[0-9]+$", "pinned": true },
+		{ "name": "tenants", "match": "tenant_*", "offload_after": "6h", "reload_mode": "blocking" },
+		{ "name": "archive", "regex": "archive", "offload_after": "never" },
+		{ "name": "single",  "match": "cat?log" }
+	]`)
+	set, err := New(policies, Defaults{OffloadAfter: 30 * time.Minute, ReloadMode: ReloadAsync})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		collection  string
+		policy      string
+		reason      string
+		offload     string
+		reloadMode  string
+		offloadable bool
+	}{
+		// A glob listed before a regex wins over it
+		{"tenant_vip_1_logs", "logs", `matched glob "tenant_*_logs"`, "1h0m0s", ReloadAsync, true},
+		// A regex listed before a glob wins over it
+		{"tenant_vip_7", "vip", `matched regex "^tenant_vip_[0-9]+$"`, "30m0s", ReloadAsync, false},
+		{"tenant_vip_x", "tenants", `matched glob "tenant_*"`, "6h0m0s", ReloadBlocking, true},
+		{"tenant_acme", "tenants", `matched glob "tenant_*"`, "6h0m0s", ReloadBlocking, true},
+		// Regexes are not anchored unless they say so
+		{"old_archive_2020", "archive", `matched regex "archive"`, "never", ReloadAsync, false},
+		{"catalog", "single", `matched glob "cat?log"`, "30m0s", ReloadAsync, true},
+		// Globs match the whole name
+		{"catalogs", "default", "no policy matched", "30m0s", ReloadAsync, true},
+		{"products", "default", "no policy matched", "30m0s", ReloadAsync, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collection, func(t *testing.T) {
+			eff := set.Resolve(tt.collection)
+			if eff.Policy != tt.policy || eff.Reason != tt.reason {
+				t.Errorf("policy = %s (%s), want %s (%s)", eff.Policy, eff.Reason, tt.policy, tt.reason)
+			}
+			if got := eff.OffloadAfter.String(); got != tt.offload {
+				t.Errorf("offload_after = %s, want %s", got, tt.offload)
+			}
+			if eff.ReloadMode != tt.reloadMode {
+				t.Errorf("reload_mode = %s, want %s", eff.ReloadMode, tt.reloadMode)
+			}
+			if eff.Offloadable() != tt.offloadable {
+				t.Errorf("offloadable = %v, want %v", eff.Offloadable(), tt.offloadable)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies string
+		want     string
+	}{
+		{"no matcher", `[{ "name": "p" }]`, "match or regex is required"},
+		{"both matchers", `[{ "name": "p", "match": "a*", "regex": "a" }]`, "not both"},
+		{"bad glob", `[{ "name": "p", "match": "[a" }]`, "invalid glob"},
+		{"bad regex", `[{ "name": "p", "regex": "(" }]`, "invalid regex"},
+		{"never drain", `[{ "name": "p", "match": "*", "drain_grace_period": "never" }]`, "drain_grace_period cannot be never"},
+		{"bad reload mode", `[{ "name": "p", "match": "*", "reload_mode": "lazy" }]`, "invalid reload_mode"},
+		{"bad cron", `[{ "name": "p", "match": "*", "prewarm": [{ "cron": "* * *" }] }]`, "prewarm[0]"},
+		{"bad timezone", `[{ "name": "p", "match": "*", "prewarm": [{ "cron": "@daily", "timezone": "Mars/Olympus" }] }]`, "invalid timezone"},
+		{"unnamed", `[{ "match": "*" }, {}]`, "policy[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(parsePolicies(t, tt.policies), Defaults{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinOffloadAfter(t *testing.T) {
+	set, err := New(parsePolicies(t, `[
+		{ "match": "a*", "offload_after": "never" },
+		{ "match": "b*", "offload_after": "10m" },
+		{ "match": "c*" }
+	]`), Defaults{OffloadAfter: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := set.MinOffloadAfter(); got != 10*time.Minute {
+		t.Errorf("MinOffloadAfter = %s, want 10m", got)
+	}
+}
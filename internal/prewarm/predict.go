@@ -0,0 +1,295 @@
+package prewarm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+	"github.com/SoyebSarkar/Hiberstack/internal/policy"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+)
+
+const week = 7 * 24 * time.Hour
+
+// Prediction decisions
+const (
+	DecisionPrewarm       = "prewarm"
+	DecisionWait          = "wait"
+	DecisionNoHistory     = "insufficient_history"
+	DecisionNotCold       = "not_cold"
+	DecisionUnpredictable = "no_likely_access"
+	DecisionPrewarmed     = "already_prewarmed"
+)
+
+// Slot is one hour of the week and how often it saw traffic.
+type Slot struct {
+	Start         time.Time `json:"start"`
+	HourOfWeek    int       `json:"hour_of_week"`
+	WeeksWithHits int       `json:"weeks_with_hits"`
+	Probability   float64   `json:"probability"`
+}
+
+// Outcome records whether a predictive prewarm was followed by traffic.
+type Outcome struct {
+	PrewarmedAt time.Time `json:"prewarmed_at"`
+	// Slot is the start of the hour the prewarm was for
+	Slot time.Time `json:"slot"`
+	// Hit is nil until the protection window has passed.
+	Hit *bool `json:"hit,omitempty"`
+}
+
+// Prediction explains what the planner thinks about one collection.
+type Prediction struct {
+	Collection    string   `json:"collection"`
+	State         string   `json:"state"`
+	WeeksObserved int      `json:"weeks_observed"`
+	Threshold     float64  `json:"threshold"`
+	Upcoming      *Slot    `json:"upcoming,omitempty"`
+	NextLikely    *Slot    `json:"next_likely,omitempty"`
+	Decision      string   `json:"decision"`
+	Reason        string   `json:"reason"`
+	LastPrewarm   *Outcome `json:"last_prewarm,omitempty"`
+}
+
+// Planner pre-warms COLD collections shortly before the hours of the week
+// in which they have usually been queried. Each hour of the week is scored
+// by the share of observed weeks in which it saw any traffic.
+type Planner struct {
+	store        *state.Store
+	lifecycleMgr Reloader
+	policies     *policy.Set
+
+	// threshold is the probability at which an hour counts as likely
+	threshold float64
+	// lead is how long before a likely hour the reload starts
+	lead time.Duration
+	// weeks of history kept and scored; minWeeks needed before predicting
+	weeks    int
+	minWeeks int
+
+	mu       sync.Mutex
+	outcomes map[string]*Outcome
+	running  inflight
+}
+
+func NewPlanner(
+	store *state.Store,
+	lifecycleMgr Reloader,
+	policies *policy.Set,
+	threshold float64,
+	lead time.Duration,
+	weeks int,
+	minWeeks int,
+) *Planner {
+	return &Planner{
+		store:        store,
+		lifecycleMgr: lifecycleMgr,
+		policies:     policies,
+		threshold:    threshold,
+		lead:         lead,
+		weeks:        weeks,
+		minWeeks:     minWeeks,
+		outcomes:     make(map[string]*Outcome),
+	}
+}
+
+// Start records access history and evaluates predictions once a minute
+// until ctx is done. History is written to state.db in one batch per
+// minute and pruned to the weeks that are scored.
+func (p *Planner) Start(ctx context.Context) {
+	p.store.RecordAccessHistory()
+	ticker := time.NewTicker(time.Minute)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				p.flushHistory()
+				return
+			case now := <-ticker.C:
+				p.runOnce(ctx, now)
+			}
+		}
+	}()
+}
+
+func (p *Planner) flushHistory() {
+	if err := p.store.FlushAccessHistory(); err != nil {
+		log.Printf("predict flush history failed: %v", err)
+	}
+}
+
+func (p *Planner) runOnce(ctx context.Context, now time.Time) {
+	p.flushHistory()
+	if err := p.store.PruneAccessHistory(now.Add(-time.Duration(p.weeks) * week)); err != nil {
+		log.Printf("predict prune history failed: %v", err)
+	}
+	p.scoreOutcomes(now)
+
+	cold, err := p.store.ListByState(state.Cold)
+	if err != nil {
+		log.Printf("predict list cold collections failed: %v", err)
+		return
+	}
+
+	for _, c := range cold {
+		pred := p.Predict(c, now)
+		if pred.Decision != DecisionPrewarm {
+			continue
+		}
+		if !p.running.start(c) {
+			continue
+		}
+		log.Printf("predict prewarm collection=%s reason=%q", c, pred.Reason)
+		slot := pred.NextLikely.Start
+		go func() {
+			defer p.running.done(c)
+			p.prewarm(ctx, c, now, slot)
+		}()
+	}
+}
+
+func (p *Planner) prewarm(ctx context.Context, collection string, now, slot time.Time) {
+	reloaded, err := reloadAndProtect(ctx, p.store, p.lifecycleMgr, p.policies.Resolve(collection))
+	if err != nil {
+		log.Printf("predict prewarm failed collection=%s err=%v", collection, err)
+		return
+	}
+	if !reloaded {
+		return
+	}
+	metrics.PredictivePrewarmTotal.Inc()
+
+	p.mu.Lock()
+	p.outcomes[collection] = &Outcome{PrewarmedAt: now, Slot: slot}
+	p.mu.Unlock()
+}
+
+// scoreOutcomes checks, once a prewarm's protection window has passed,
+// whether the collection was actually queried in the meantime.
+func (p *Planner) scoreOutcomes(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for c, o := range p.outcomes {
+		if o.Hit != nil {
+			continue
+		}
+		window := p.policies.Resolve(c).PrewarmProtect.Duration
+		if window <= 0 {
+			window = time.Hour
+		}
+		if now.Before(o.PrewarmedAt.Add(window)) {
+			continue
+		}
+
+		hit := p.store.WasRecentlyAccessed(c, now.Sub(o.PrewarmedAt))
+		o.Hit = &hit
+		outcome := "miss"
+		if hit {
+			outcome = "hit"
+		}
+		metrics.PredictivePrewarmOutcomes.WithLabelValues(outcome).Inc()
+		log.Printf("predict outcome collection=%s outcome=%s", c, outcome)
+	}
+}
+
+// Predict scores the next 24 hours for the collection and decides whether
+// it should be pre-warmed now.
+func (p *Planner) Predict(collection string, now time.Time) Prediction {
+	pred := Prediction{
+		Collection: collection,
+		State:      string(p.store.Get(collection)),
+		Threshold:  p.threshold,
+	}
+
+	p.mu.Lock()
+	if o, ok := p.outcomes[collection]; ok {
+		copied := *o
+		pred.LastPrewarm = &copied
+	}
+	p.mu.Unlock()
+
+	history, err := p.store.AccessHistory(collection, now.Add(-time.Duration(p.weeks)*week))
+	if err != nil {
+		pred.Decision = DecisionNoHistory
+		pred.Reason = err.Error()
+		return pred
+	}
+	if len(history) == 0 {
+		pred.Decision = DecisionNoHistory
+		pred.Reason = "no recorded accesses"
+		return pred
+	}
+
+	// Weeks since the first recorded access, capped at the retention
+	pred.WeeksObserved = min(int(now.Sub(history[0].Hour)/week)+1, p.weeks)
+
+	// For every hour of the week, the distinct weeks it saw traffic in
+	seen := make(map[int]map[int]bool)
+	for _, b := range history {
+		how := hourOfWeek(b.Hour)
+		if seen[how] == nil {
+			seen[how] = make(map[int]bool)
+		}
+		seen[how][int(now.Sub(b.Hour)/week)] = true
+	}
+
+	start := now.UTC().Truncate(time.Hour).Add(time.Hour)
+	for i := 0; i < 24; i++ {
+		t := start.Add(time.Duration(i) * time.Hour)
+		how := hourOfWeek(t)
+		slot := Slot{
+			Start:         t,
+			HourOfWeek:    how,
+			WeeksWithHits: len(seen[how]),
+			Probability:   float64(len(seen[how])) / float64(pred.WeeksObserved),
+		}
+		if i == 0 {
+			pred.Upcoming = &slot
+		}
+		if slot.Probability >= p.threshold {
+			pred.NextLikely = &slot
+			break
+		}
+	}
+
+	switch {
+	case pred.State != string(state.Cold):
+		pred.Decision = DecisionNotCold
+		pred.Reason = "only COLD collections are pre-warmed"
+	case pred.WeeksObserved < p.minWeeks:
+		pred.Decision = DecisionNoHistory
+		pred.Reason = "history covers fewer weeks than required"
+	case pred.NextLikely == nil:
+		pred.Decision = DecisionUnpredictable
+		pred.Reason = "no hour in the next 24h reaches the threshold"
+	case pred.LastPrewarm != nil && pred.LastPrewarm.Slot.Equal(pred.NextLikely.Start):
+		// Offloaded again before the hour it was loaded for; reloading
+		// it once more would only repeat that
+		pred.Decision = DecisionPrewarmed
+		pred.Reason = "already pre-warmed for this hour"
+	case !now.Before(pred.NextLikely.Start.Add(-p.lead)):
+		pred.Decision = DecisionPrewarm
+		pred.Reason = explain(pred.NextLikely, pred.WeeksObserved)
+	default:
+		pred.Decision = DecisionWait
+		pred.Reason = fmt.Sprintf("%s; reload starts %s before", explain(pred.NextLikely, pred.WeeksObserved), p.lead)
+	}
+	return pred
+}
+
+func explain(s *Slot, weeks int) string {
+	return fmt.Sprintf("%s %s UTC had traffic in %d of the last %d weeks",
+		s.Start.Weekday(), s.Start.Format("15:04"), s.WeeksWithHits, weeks)
+}
+
+// hourOfWeek numbers the hours of a week from Sunday 00:00 UTC.
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
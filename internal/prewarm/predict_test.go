@@ -0,0 +1,85 @@
+package prewarm
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/policy"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+)
+
+// countingReloader reports every reload as done but leaves the state
+// alone, as if the collection had been offloaded again right after.
+type countingReloader struct {
+	calls atomic.Int32
+}
+
+func (r *countingReloader) Reload(ctx context.Context, collection string) (bool, error) {
+	r.calls.Add(1)
+	return true, nil
+}
+
+func TestPlannerPrewarmsOncePerSlot(t *testing.T) {
+	store, err := state.NewSQLite(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies, err := policy.New(nil, policy.Defaults{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Register("books", state.Cold); err != nil {
+		t.Fatal(err)
+	}
+	store.RecordAccessHistory()
+	store.Touch("books")
+	if err := store.FlushAccessHistory(); err != nil {
+		t.Fatal(err)
+	}
+	history, err := store.AccessHistory("books", time.Time{})
+	if err != nil || len(history) != 1 {
+		t.Fatalf("history = %v, %v", history, err)
+	}
+
+	reloader := &countingReloader{}
+	p := NewPlanner(store, reloader, policies, 0.5, time.Hour, 1, 1)
+
+	// Ten minutes before the same hour a week later
+	slot := history[0].Hour.Add(week)
+	for i, now := range []time.Time{
+		slot.Add(-10 * time.Minute),
+		slot.Add(-9 * time.Minute),
+		slot.Add(-time.Minute),
+	} {
+		p.runOnce(context.Background(), now)
+		waitIdle(t, p, "books")
+		if got := reloader.calls.Load(); got != 1 {
+			t.Fatalf("pass %d: %d reloads, want 1", i, got)
+		}
+	}
+
+	pred := p.Predict("books", slot.Add(-time.Minute))
+	if pred.Decision != DecisionPrewarmed {
+		t.Errorf("decision = %s, want %s", pred.Decision, DecisionPrewarmed)
+	}
+	if pred.LastPrewarm == nil || !pred.LastPrewarm.Slot.Equal(slot) {
+		t.Errorf("last prewarm = %+v, want slot %s", pred.LastPrewarm, slot)
+	}
+}
+
+func waitIdle(t *testing.T, p *Planner, collection string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, running := p.running.m.Load(collection); !running {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("prewarm of %s still running", collection)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
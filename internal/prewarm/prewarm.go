@@ -0,0 +1,143 @@
+package prewarm
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+	"github.com/SoyebSarkar/Hiberstack/internal/policy"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+)
+
+type Reloader interface {
+	Reload(ctx context.Context, collection string) (bool, error)
+}
+
+// Prewarmer reloads COLD collections when one of their policy's prewarm
+// schedules fires. Reloads go through lifecycle.Manager, so they queue
+// behind MaxConcurrentReloads like any other.
+type Prewarmer struct {
+	store        *state.Store
+	lifecycleMgr Reloader
+	policies     *policy.Set
+
+	// last is the end of the window checked by the previous pass
+	last    time.Time
+	running inflight
+}
+
+func New(
+	store *state.Store,
+	lifecycleMgr Reloader,
+	policies *policy.Set,
+) *Prewarmer {
+	return &Prewarmer{
+		store:        store,
+		lifecycleMgr: lifecycleMgr,
+		policies:     policies,
+	}
+}
+
+// Start checks the schedules once a minute, the resolution of cron.
+// Firings missed while Hiberstack was down are not caught up. The
+// schedules are no longer checked once ctx is done.
+func (p *Prewarmer) Start(ctx context.Context) {
+	p.last = time.Now()
+	ticker := time.NewTicker(time.Minute)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				p.runOnce(ctx, now)
+			}
+		}
+	}()
+}
+
+func (p *Prewarmer) runOnce(ctx context.Context, now time.Time) {
+	since := p.last
+	p.last = now
+
+	cold, err := p.store.ListByState(state.Cold)
+	if err != nil {
+		log.Printf("prewarm list cold collections failed: %v", err)
+		return
+	}
+
+	for _, c := range cold {
+		eff := p.policies.Resolve(c)
+		for _, pw := range eff.Prewarm {
+			next := pw.Next(since)
+			if next.IsZero() || next.After(now) {
+				continue
+			}
+			if !p.running.start(c) {
+				break
+			}
+			log.Printf("prewarm collection=%s policy=%s cron=%q fired=%s", c, eff.Policy, pw.Cron, next.Format(time.RFC3339))
+			go func() {
+				defer p.running.done(c)
+				p.prewarm(ctx, eff)
+			}()
+			break
+		}
+	}
+}
+
+func (p *Prewarmer) prewarm(ctx context.Context, eff policy.Effective) {
+	reloaded, err := reloadAndProtect(ctx, p.store, p.lifecycleMgr, eff)
+	if err != nil {
+		log.Printf("prewarm failed collection=%s err=%v", eff.Collection, err)
+		return
+	}
+	if reloaded {
+		metrics.PrewarmTotal.Inc()
+	}
+}
+
+// reloadAndProtect reloads the collection and protects it for the policy's
+// prewarm_protect window, so it stays loaded until the expected traffic
+// has had a chance to arrive. Nothing is protected if the collection was
+// reloaded by someone else; reloaded reports whether this call did it. A
+// reload already started is bounded by the lifecycle manager's shutdown
+// deadline rather than by ctx.
+func reloadAndProtect(ctx context.Context, store *state.Store, lifecycleMgr Reloader, eff policy.Effective) (reloaded bool, err error) {
+	collection := eff.Collection
+	reloaded, err = lifecycleMgr.Reload(context.WithoutCancel(ctx), collection)
+	if err != nil || !reloaded {
+		return false, err
+	}
+
+	if eff.PrewarmProtect.Duration > 0 {
+		until := time.Now().Add(eff.PrewarmProtect.Duration)
+		if err := store.Protect(collection, until); err != nil {
+			return true, err
+		}
+		log.Printf("prewarm collection=%s protected_until=%s", collection, until.Format(time.RFC3339))
+	}
+	return true, nil
+}
+
+// inflight tracks the collections a prewarm is running for, so a schedule
+// or prediction that keeps firing while a reload waits for a slot does not
+// queue another one.
+type inflight struct {
+	m sync.Map
+}
+
+// start reports whether no prewarm was running for the collection, and
+// marks one as running.
+func (f *inflight) start(collection string) bool {
+	_, running := f.m.LoadOrStore(collection, struct{}{})
+	return !running
+}
+
+func (f *inflight) done(collection string) {
+	f.m.Delete(collection)
+}
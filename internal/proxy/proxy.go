@@ -2,57 +2,73 @@ package proxy
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
-	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/SoyebSarkar/Hiberstack/internal/config"
-	"github.com/SoyebSarkar/Hiberstack/internal/lifecycle"
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
 	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+	"github.com/SoyebSarkar/Hiberstack/internal/policy"
 	"github.com/SoyebSarkar/Hiberstack/internal/state"
 )
 
 type Reloader interface {
-	Reload(collection string)
+	Reload(ctx context.Context, collection string) (bool, error)
 }
 
 type Proxy struct {
 	rp           *httputil.ReverseProxy
-	lifecycleMgr *lifecycle.Manager
-	reloadMode   config.ReloadMode
+	lifecycleMgr Reloader
+	policies     *policy.Set
 	stateStore   *state.Store
+	paths        engine.PathMapper
 	inflight     sync.Map
 }
 
 func New(
-	target string,
-	lifecycleMgr *lifecycle.Manager,
+	upstream Upstream,
+	paths engine.PathMapper,
+	lifecycleMgr Reloader,
 	stateStore *state.Store,
-	reloadMode config.ReloadMode,
+	policies *policy.Set,
 ) (*Proxy, error) {
 
-	u, err := url.Parse(target)
-	if err != nil {
-		return nil, err
-	}
-
 	p := &Proxy{
 		lifecycleMgr: lifecycleMgr,
+		paths:        paths,
 		stateStore:   stateStore,
-		reloadMode:   reloadMode,
+		policies:     policies,
 	}
 
-	rp := httputil.NewSingleHostReverseProxy(u)
+	rp := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			target := targets(upstream, paths, req)[0]
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			if target.Path != "" {
+				req.URL.Path = strings.TrimSuffix(target.Path, "/") + req.URL.Path
+				req.URL.RawPath = ""
+			}
+			if _, ok := req.Header["User-Agent"]; !ok {
+				// Don't let Go's default User-Agent through
+				req.Header.Set("User-Agent", "")
+			}
+		},
+		Transport: &failover{upstream: upstream, paths: paths, base: http.DefaultTransport},
+	}
 
 	// MODIFY RESPONSE: async reload only
 	rp.ModifyResponse = func(resp *http.Response) error {
-		collection := extractCollection(resp.Request.URL.Path)
+		collection := p.paths.CollectionFromPath(resp.Request.URL.Path)
 		if collection == "" {
 			return nil
 		}
@@ -74,7 +90,13 @@ func New(
 
 		current := p.stateStore.Get(collection)
 
-		if current == state.Cold && p.reloadMode == config.ReloadAsync {
+		// A miss on a COLD collection is still an access; it matters most
+		// for predicting when to pre-warm
+		if current == state.Cold {
+			p.stateStore.Touch(collection)
+		}
+
+		if current == state.Cold && p.reloadMode(collection) == config.ReloadAsync {
 			log.Println("async cold reload triggered:", collection)
 
 			ch, loaded := p.inflight.LoadOrStore(collection, make(chan struct{}))
@@ -82,7 +104,7 @@ func New(
 
 			if !loaded {
 				go func() {
-					p.lifecycleMgr.Reload(collection)
+					p.lifecycleMgr.Reload(context.Background(), collection)
 					close(done)
 					p.inflight.Delete(collection)
 				}()
@@ -96,6 +118,11 @@ func New(
 			return replaceWithWarming(resp)
 		}
 
+		// Failed → explain instead of a bare 404
+		if current == state.Failed {
+			return replaceWithFailed(resp, collection, p.stateStore.GetFailure(collection))
+		}
+
 		// Any other case → pass through
 		return nil
 	}
@@ -105,7 +132,7 @@ func New(
 }
 
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	collection := extractCollection(r.URL.Path)
+	collection := p.paths.CollectionFromPath(r.URL.Path)
 
 	// No collection → pass through
 	if collection == "" {
@@ -127,7 +154,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// Async reload mode → ModifyResponse handles reload
-		if p.reloadMode != config.ReloadBlocking {
+		if p.reloadMode(collection) != config.ReloadBlocking {
 			p.rp.ServeHTTP(w, r)
 			return
 		}
@@ -140,7 +167,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			if !loaded {
 				go func() {
 					log.Printf("async reload triggered by write collection=%s", collection)
-					p.lifecycleMgr.Reload(collection)
+					p.lifecycleMgr.Reload(context.Background(), collection)
 					close(done)
 					p.inflight.Delete(collection)
 				}()
@@ -167,7 +194,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// --------------------
 
 	// Async reload mode → ModifyResponse handles reload
-	if p.reloadMode != config.ReloadBlocking {
+	if p.reloadMode(collection) != config.ReloadBlocking {
 		p.rp.ServeHTTP(w, r)
 		return
 	}
@@ -201,7 +228,7 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	if !loaded {
 		go func() {
-			p.lifecycleMgr.Reload(collection)
+			p.lifecycleMgr.Reload(context.Background(), collection)
 			close(done)
 			p.inflight.Delete(collection)
 		}()
@@ -232,13 +259,9 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // Helpers
 // -------------------------
 
-func extractCollection(path string) string {
-	// Expected: /collections/{name}/...
-	parts := strings.Split(path, "/")
-	if len(parts) >= 3 && parts[1] == "collections" {
-		return parts[2]
-	}
-	return ""
+// reloadMode is the reload mode of the policy that applies to the collection.
+func (p *Proxy) reloadMode(collection string) config.ReloadMode {
+	return config.ReloadMode(p.policies.Resolve(collection).ReloadMode)
 }
 
 func replaceWithWarming(resp *http.Response) error {
@@ -252,7 +275,47 @@ func replaceWithWarming(resp *http.Response) error {
 	return nil
 }
 
+func replaceWithFailed(resp *http.Response, collection string, failure *state.Failure) error {
+	body := map[string]any{
+		"message":    "collection " + collection + " is FAILED; POST /admin/retry/" + collection + " or /admin/reset/" + collection,
+		"collection": collection,
+		"state":      state.Failed,
+	}
+	if failure != nil {
+		body["failure"] = failure
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp.StatusCode = http.StatusServiceUnavailable
+	resp.Status = "503 Service Unavailable"
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	return nil
+}
+
+var readOnlyPostSuffixes = []string{
+	"/search",   // Meilisearch
+	"/_search",  // OpenSearch / Elasticsearch
+	"/_msearch", // OpenSearch / Elasticsearch
+	"/_count",   // OpenSearch / Elasticsearch
+	"/_mget",    // OpenSearch / Elasticsearch
+}
+
 func isWriteRequest(r *http.Request) bool {
+	// Search APIs that take a POST body never modify the collection
+	if r.Method == http.MethodPost {
+		for _, suffix := range readOnlyPostSuffixes {
+			if strings.HasSuffix(r.URL.Path, suffix) {
+				return false
+			}
+		}
+	}
+
 	switch r.Method {
 	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
 		return true
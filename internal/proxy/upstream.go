@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+)
+
+// Upstream chooses the engine node each request is forwarded to.
+type Upstream interface {
+	// Targets lists the nodes to try, best first. Only the first one is
+	// used for writes.
+	Targets(write bool) []*url.URL
+	// MarkDown reports a node that could not be reached.
+	MarkDown(node *url.URL)
+}
+
+// Router is an Upstream spread over independent instances that each serve
+// their own collections. Requests that are not collection-scoped still go
+// to Targets.
+type Router interface {
+	Upstream
+	CollectionTargets(collection string, write bool) []*url.URL
+}
+
+// SingleHost is an Upstream of one node, for engines that are reached
+// through a single address.
+type SingleHost struct {
+	url *url.URL
+}
+
+func NewSingleHost(target string) (*SingleHost, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	return &SingleHost{url: u}, nil
+}
+
+func (s *SingleHost) Targets(write bool) []*url.URL {
+	return []*url.URL{s.url}
+}
+
+func (s *SingleHost) MarkDown(node *url.URL) {}
+
+// targets picks the nodes for a request, routing collection-scoped requests
+// when the upstream is a Router.
+func targets(upstream Upstream, paths engine.PathMapper, req *http.Request) []*url.URL {
+	write := isWriteRequest(req)
+	if router, ok := upstream.(Router); ok {
+		if collection := paths.CollectionFromPath(req.URL.Path); collection != "" {
+			return router.CollectionTargets(collection, write)
+		}
+	}
+	return upstream.Targets(write)
+}
+
+// failover sends reads to the next node when a node cannot be reached or
+// answers 503. Writes are never repeated, since they may have been applied.
+type failover struct {
+	upstream Upstream
+	paths    engine.PathMapper
+	base     http.RoundTripper
+}
+
+func (f *failover) RoundTrip(req *http.Request) (*http.Response, error) {
+	nodes := targets(f.upstream, f.paths, req)
+	if isWriteRequest(req) || len(nodes) < 2 {
+		return f.base.RoundTrip(req)
+	}
+
+	// Reads are replayed, so their bodies (search queries) are buffered
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	var lastErr error
+	for i, target := range nodes {
+		out := req.Clone(req.Context())
+		out.URL.Scheme = target.Scheme
+		out.URL.Host = target.Host
+		if body != nil {
+			out.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := f.base.RoundTrip(out)
+		last := i == len(nodes)-1
+		switch {
+		case err != nil:
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+			f.upstream.MarkDown(target)
+			lastErr = err
+		case resp.StatusCode == http.StatusServiceUnavailable && !last:
+			resp.Body.Close()
+			lastErr = errors.New(resp.Status)
+		default:
+			return resp, nil
+		}
+
+		if !last {
+			log.Printf("proxy failover path=%s node=%s err=%v", req.URL.Path, target.Host, lastErr)
+			metrics.ProxyFailoverTotal.Inc()
+		}
+	}
+	return nil, lastErr
+}
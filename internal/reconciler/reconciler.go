@@ -0,0 +1,197 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+	"github.com/SoyebSarkar/Hiberstack/snapshot"
+)
+
+// DriftKind names a disagreement between state.db and reality that the
+// reconciler does not fix on its own.
+type DriftKind string
+
+const (
+	// DriftMissing is a HOT collection that is gone from the engine and
+	// has no snapshot to reload from.
+	DriftMissing DriftKind = "hot_missing"
+	// DriftUnexpected is a COLD collection that exists in the engine again,
+	// typically recreated outside Hiberstack. Its snapshot may be stale.
+	DriftUnexpected DriftKind = "cold_present"
+)
+
+var driftKinds = []DriftKind{DriftMissing, DriftUnexpected}
+
+type Drift struct {
+	Collection string    `json:"collection"`
+	Kind       DriftKind `json:"kind"`
+	State      string    `json:"state"`
+}
+
+// Lister is the part of engine.Engine the reconciler needs.
+type Lister interface {
+	ListCollections(ctx context.Context) ([]string, error)
+}
+
+// Reconciler periodically compares state.db with the engine's collections
+// and the snapshot store. Unambiguous differences are fixed:
+//
+//   - collections only the engine knows are registered as HOT
+//   - snapshots nobody tracks are registered as COLD
+//   - HOT collections deleted from the engine fall back to COLD when a
+//     snapshot exists
+//
+// Everything else is reported as drift through Drift() and metrics.
+type Reconciler struct {
+	store     *state.Store
+	engine    Lister
+	snapshots snapshot.Store
+	interval  time.Duration
+
+	mu    sync.Mutex
+	drift []Drift
+}
+
+func New(
+	store *state.Store,
+	engine Lister,
+	snapshots snapshot.Store,
+	interval time.Duration,
+) *Reconciler {
+	return &Reconciler{
+		store:     store,
+		engine:    engine,
+		snapshots: snapshots,
+		interval:  interval,
+	}
+}
+
+// Start reconciles immediately and then every interval until ctx is done.
+func (r *Reconciler) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+
+	go func() {
+		defer ticker.Stop()
+		r.runOnce(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.runOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Drift returns what the last pass found and left alone.
+func (r *Reconciler) Drift() []Drift {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.drift)
+}
+
+func (r *Reconciler) runOnce(ctx context.Context) {
+	// States are read before the engine is listed: an offload or reload
+	// finishing in between then shows up as a failed compare-and-swap
+	// rather than as a false fix.
+	known, err := r.store.List()
+	if err != nil {
+		log.Printf("reconciler list state failed: %v", err)
+		return
+	}
+
+	// With some instances of a pool unreachable, their collections are
+	// neither present nor missing; they are left alone below
+	collections, listErr := r.engine.ListCollections(ctx)
+	var partial *engine.PartialError
+	if listErr != nil && !errors.As(listErr, &partial) {
+		log.Printf("reconciler list engine collections failed: %v", listErr)
+		return
+	}
+	snapshots, err := r.snapshots.List()
+	if err != nil {
+		log.Printf("reconciler list snapshots failed: %v", err)
+		return
+	}
+
+	inEngine := toSet(collections)
+	hasSnapshot := toSet(snapshots)
+	var drift []Drift
+
+	for _, c := range collections {
+		if _, ok := known[c]; ok {
+			continue
+		}
+		r.register(c, state.Hot)
+	}
+	for _, c := range snapshots {
+		if _, ok := known[c]; ok || inEngine[c] || engine.Unknown(listErr, "") {
+			continue
+		}
+		r.register(c, state.Cold)
+	}
+
+	for c, st := range known {
+		if !inEngine[c] && engine.Unknown(listErr, r.store.Placement(c)) {
+			continue
+		}
+		switch {
+		case st == state.Hot && !inEngine[c] && hasSnapshot[c]:
+			if err := r.store.Transition(c, state.Hot, state.Cold); err != nil {
+				log.Printf("reconciler collection=%s fix skipped: %v", c, err)
+				continue
+			}
+			log.Printf("reconciler collection=%s action=mark_cold reason=deleted_from_engine", c)
+			metrics.ReconcileFixesTotal.WithLabelValues("mark_cold").Inc()
+
+		case st == state.Hot && !inEngine[c]:
+			drift = append(drift, Drift{Collection: c, Kind: DriftMissing, State: string(st)})
+
+		case st == state.Cold && inEngine[c]:
+			drift = append(drift, Drift{Collection: c, Kind: DriftUnexpected, State: string(st)})
+		}
+	}
+
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Collection < drift[j].Collection })
+	r.mu.Lock()
+	r.drift = drift
+	r.mu.Unlock()
+
+	counts := make(map[DriftKind]int)
+	for _, d := range drift {
+		counts[d.Kind]++
+		log.Printf("reconciler collection=%s drift=%s", d.Collection, d.Kind)
+	}
+	for _, kind := range driftKinds {
+		metrics.StateDrift.WithLabelValues(string(kind)).Set(float64(counts[kind]))
+	}
+}
+
+func (r *Reconciler) register(collection string, st state.State) {
+	added, err := r.store.Register(collection, st)
+	if err != nil {
+		log.Printf("reconciler collection=%s register failed: %v", collection, err)
+		return
+	}
+	if added {
+		log.Printf("reconciler collection=%s action=register state=%s", collection, st)
+		metrics.ReconcileFixesTotal.WithLabelValues("register").Inc()
+	}
+}
+
+func toSet(names []string) map[string]bool {
+	out := make(map[string]bool, len(names))
+	for _, n := range names {
+		out[n] = true
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/engine"
+	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+	"github.com/SoyebSarkar/Hiberstack/internal/state"
+)
+
+// MemoryEngine is what memory-pressure eviction needs from the engine.
+type MemoryEngine interface {
+	engine.MemoryReporter
+	DocumentCount(ctx context.Context, collection string) (int64, error)
+}
+
+// MemoryPressure offloads least recently used HOT collections whenever the
+// engine's memory use crosses HighWater, until it is back under LowWater.
+// Both are fractions of Budget, or of the engine host's total memory when
+// Budget is zero. An engine spread over instances is checked per instance.
+type MemoryPressure struct {
+	Engine    MemoryEngine
+	HighWater float64
+	LowWater  float64
+	Budget    int64
+	Interval  time.Duration
+}
+
+func (s *Scheduler) watchMemory(ctx context.Context) {
+	every(ctx, func() time.Duration {
+		return s.memoryPressure().Interval
+	}, s.memoryChanged, func() {
+		s.evictForMemory(ctx)
+	})
+}
+
+// SetMemoryPressure replaces the water marks, budget and check interval of
+// memory-pressure eviction. It returns false if eviction was not enabled
+// at startup, since the engine was never checked for memory reporting.
+func (s *Scheduler) SetMemoryPressure(highWater, lowWater float64, budget int64, interval time.Duration) bool {
+	s.mu.Lock()
+	if s.memory == nil {
+		s.mu.Unlock()
+		return false
+	}
+	s.memory = &MemoryPressure{
+		Engine:    s.memory.Engine,
+		HighWater: highWater,
+		LowWater:  lowWater,
+		Budget:    budget,
+		Interval:  interval,
+	}
+	s.mu.Unlock()
+	notify(s.memoryChanged)
+	return true
+}
+
+func (s *Scheduler) memoryPressure() *MemoryPressure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memory
+}
+
+func (s *Scheduler) evictForMemory(ctx context.Context) {
+	mp := s.memoryPressure()
+
+	usage := make(map[string]engine.Memory)
+	if instances, ok := mp.Engine.(engine.InstanceMemoryReporter); ok {
+		var err error
+		if usage, err = instances.InstanceMemoryUsage(ctx); err != nil {
+			log.Printf("scheduler memory check failed: %v", err)
+			return
+		}
+	} else {
+		mem, err := mp.Engine.MemoryUsage(ctx)
+		if err != nil {
+			log.Printf("scheduler memory check failed: %v", err)
+			return
+		}
+		usage[""] = mem
+	}
+
+	hot, err := s.store.ListHotLRU()
+	if err != nil {
+		log.Printf("scheduler memory list collections failed: %v", err)
+		return
+	}
+
+	// The metrics follow the instance closest to its high water mark
+	fullest := -1.0
+	for instance, mem := range usage {
+		budget := mp.Budget
+		if budget == 0 {
+			budget = mem.Total
+		}
+		if budget > 0 && float64(mem.Used)/float64(budget) > fullest {
+			fullest = float64(mem.Used) / float64(budget)
+			metrics.EngineMemoryUsed.Set(float64(mem.Used))
+			metrics.EngineMemoryBudget.Set(float64(budget))
+		}
+		s.evictFrom(ctx, mp, instance, mem, budget, hot)
+	}
+}
+
+// evictFrom evicts from one engine instance, or from the whole engine if
+// instance is "", only counting the collections placed there against its
+// memory. Budget applies to each instance.
+func (s *Scheduler) evictFrom(ctx context.Context, mp *MemoryPressure, instance string, usage engine.Memory, budget int64, all []state.Usage) {
+	hot := all
+	if instance != "" {
+		hot = nil
+		for _, u := range all {
+			if u.Instance == instance {
+				hot = append(hot, u)
+			}
+		}
+	}
+	s.estimateSizes(ctx, mp.Engine, hot, usage.Used)
+
+	// Offloads already under way will free their share soon
+	pending, err := s.store.TotalSize(state.Draining, instance)
+	if err != nil {
+		log.Printf("scheduler memory pending size failed: %v", err)
+		return
+	}
+
+	where := ""
+	if instance != "" {
+		where = " instance=" + instance
+	}
+
+	projected := usage.Used - pending
+	high := int64(mp.HighWater * float64(budget))
+	if projected <= high {
+		return
+	}
+
+	low := int64(mp.LowWater * float64(budget))
+	log.Printf("scheduler memory pressure%s used=%d pending_offload=%d high_water=%d low_water=%d", where, usage.Used, pending, high, low)
+
+	for _, u := range hot {
+		if projected <= low {
+			break
+		}
+
+		p := s.policies.Resolve(u.Collection)
+		if u.Pinned || u.Protected || !p.Offloadable() {
+			continue
+		}
+		if err := s.store.Transition(u.Collection, state.Hot, state.Draining); err != nil {
+			log.Printf("scheduler skip collection=%s err=%v", u.Collection, err)
+			continue
+		}
+
+		projected -= u.SizeBytes
+		log.Printf("scheduler evicting collection=%s%s reason=memory_pressure size_estimate=%d last_accessed=%s", u.Collection, where, u.SizeBytes, u.LastAccessedAt.Format(time.RFC3339))
+		metrics.MemoryEvictionsTotal.Inc()
+		go s.drainAndOffload(ctx, p, false)
+	}
+
+	if projected > low {
+		log.Printf("scheduler memory pressure persists%s projected=%d low_water=%d: no more evictable collections", where, projected, low)
+	}
+}
+
+// estimateSizes apportions the engine's memory use to HOT collections by
+// their share of documents, and records the estimate in state.db.
+func (s *Scheduler) estimateSizes(ctx context.Context, eng MemoryEngine, hot []state.Usage, used int64) {
+	counts := make([]int64, len(hot))
+	var total int64
+	for i, u := range hot {
+		n, err := eng.DocumentCount(ctx, u.Collection)
+		if err != nil {
+			// Keep the previous estimate
+			counts[i] = -1
+			continue
+		}
+		counts[i] = n
+		total += n
+	}
+	if total == 0 {
+		return
+	}
+
+	for i := range hot {
+		if counts[i] < 0 {
+			continue
+		}
+		hot[i].SizeBytes = int64(float64(used) * float64(counts[i]) / float64(total))
+		s.store.SetSize(hot[i].Collection, hot[i].SizeBytes)
+	}
+}
@@ -1,83 +1,188 @@
 package scheduler
 
 import (
+	"context"
 	"log"
+	"sync"
 	"time"
 
-	"github.com/SoyebSarkar/Hiberstack/internal/lifecycle"
 	"github.com/SoyebSarkar/Hiberstack/internal/metrics"
+	"github.com/SoyebSarkar/Hiberstack/internal/policy"
 	"github.com/SoyebSarkar/Hiberstack/internal/state"
 )
 
 type Offloader interface {
-	Offload(collection string) error
+	Offload(ctx context.Context, collection string) error
 }
 
 type Scheduler struct {
 	store        *state.Store
-	lifecycleMgr *lifecycle.Manager
-	offloadAfter time.Duration
-	gracePeriod  time.Duration
-	interval     time.Duration
+	lifecycleMgr Offloader
+	policies     *policy.Set
+
+	// mu guards the settings below, which can change while running
+	mu       sync.Mutex
+	interval time.Duration
+	// idle enables offloading collections idle past their threshold
+	idle bool
+	// memory enables memory-pressure eviction when non-nil
+	memory *MemoryPressure
+
+	intervalChanged chan struct{}
+	memoryChanged   chan struct{}
 }
 
 func New(
 	store *state.Store,
-	lifecycleMgr *lifecycle.Manager,
-	offloadAfter time.Duration,
-	drainGracePeriod time.Duration,
+	lifecycleMgr Offloader,
+	policies *policy.Set,
 	interval time.Duration,
+	idle bool,
+	memory *MemoryPressure,
 ) *Scheduler {
 	return &Scheduler{
 		store:        store,
 		lifecycleMgr: lifecycleMgr,
-		offloadAfter: offloadAfter,
-		gracePeriod:  drainGracePeriod,
+		policies:     policies,
 		interval:     interval,
+		idle:         idle,
+		memory:       memory,
+
+		intervalChanged: make(chan struct{}, 1),
+		memoryChanged:   make(chan struct{}, 1),
 	}
 }
 
-func (s *Scheduler) Start() {
-	ticker := time.NewTicker(s.interval)
+// Start runs the scheduler until ctx is done. Drains still waiting out
+// their grace period are then returned to HOT; offloads already running
+// are left to lifecycle.Manager.Shutdown.
+func (s *Scheduler) Start(ctx context.Context) {
+	go every(ctx, s.currentInterval, s.intervalChanged, func() {
+		s.mu.Lock()
+		idle := s.idle
+		s.mu.Unlock()
+		if idle {
+			s.runOnce(ctx)
+		}
+		metrics.UpdateStateGauges(s.store)
+	})
+
+	if s.memory != nil {
+		go s.watchMemory(ctx)
+	}
+}
+
+// SetInterval changes how often idle collections are looked for. The new
+// interval takes effect immediately.
+func (s *Scheduler) SetInterval(d time.Duration) {
+	s.mu.Lock()
+	s.interval = d
+	s.mu.Unlock()
+	notify(s.intervalChanged)
+}
+
+// SetIdle turns offloading of idle collections on or off.
+func (s *Scheduler) SetIdle(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idle = enabled
+}
+
+func (s *Scheduler) currentInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interval
+}
 
-	go func() {
-		for range ticker.C {
-			s.runOnce()
-			metrics.UpdateStateGauges(s.store)
+// every calls fn at the interval returned by interval until ctx is done,
+// restarting the ticker whenever changed is signalled.
+func every(ctx context.Context, interval func() time.Duration, changed <-chan struct{}, fn func()) {
+	ticker := time.NewTicker(interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		case <-changed:
+			ticker.Reset(interval())
 		}
-	}()
+	}
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
 }
 
-func (s *Scheduler) runOnce() {
-	collections := s.store.ListHotOlderThan(s.offloadAfter)
+func (s *Scheduler) runOnce(ctx context.Context) {
+	// Candidates have been idle for the shortest threshold of any policy;
+	// each one is then checked against its own policy
+	collections := s.store.ListHotOlderThan(s.policies.MinOffloadAfter())
 
 	for _, c := range collections {
-		log.Printf("scheduler marking draining collection=%s idle_for=%s", c, s.offloadAfter.String())
-		s.store.Set(c, state.Draining)
-		go s.drainAndOffload(c)
+		p := s.policies.Resolve(c)
+		if !p.Offloadable() {
+			continue
+		}
+		if s.store.WasRecentlyAccessed(c, p.OffloadAfter.Duration) {
+			continue
+		}
+
+		if err := s.store.Transition(c, state.Hot, state.Draining); err != nil {
+			log.Printf("scheduler skip collection=%s err=%v", c, err)
+			continue
+		}
+		log.Printf("scheduler marking draining collection=%s idle_for=%s policy=%s", c, p.OffloadAfter, p.Policy)
+		go s.drainAndOffload(ctx, p, true)
 	}
 }
 
-func (s *Scheduler) drainAndOffload(collection string) {
-	time.Sleep(s.gracePeriod)
+// drainAndOffload waits out the drain grace period and offloads the
+// collection. With cancelOnActivity, renewed access within the idle
+// threshold returns it to HOT instead.
+func (s *Scheduler) drainAndOffload(ctx context.Context, p policy.Effective, cancelOnActivity bool) {
+	collection := p.Collection
+	stopping := false
+	select {
+	case <-time.After(p.DrainGracePeriod.Duration):
+	case <-ctx.Done():
+		stopping = true
+	}
 
 	// State might have changed
 	if s.store.Get(collection) != state.Draining {
 		return
 	}
 
-	// Activity resumed → cancel offload
-	if s.store.WasRecentlyAccessed(collection, s.offloadAfter) {
-		log.Printf("scheduler cancel offload collection=%s reason=activity_resumed", collection)
-		log.Println("activity resumed, reverting to HOT:", collection)
-		s.store.Set(collection, state.Hot)
+	// Shutting down, activity resumed, pinned or protected meanwhile → cancel offload
+	reason := ""
+	if stopping {
+		reason = "shutdown"
+	} else if cancelOnActivity && s.store.WasRecentlyAccessed(collection, p.OffloadAfter.Duration) {
+		reason = "activity_resumed"
+	} else if pinned, _ := s.store.Pinned(collection); pinned {
+		reason = "pinned"
+	} else if !s.store.ProtectedUntil(collection).IsZero() {
+		reason = "protected"
+	}
+	if reason != "" {
+		log.Printf("scheduler cancel offload collection=%s reason=%s", collection, reason)
+		if err := s.store.Transition(collection, state.Draining, state.Hot); err != nil {
+			log.Printf("scheduler revert failed collection=%s err=%v", collection, err)
+		}
 		return
 	}
 
 	log.Println("scheduler offloading after drain:", collection)
-	if err := s.lifecycleMgr.Offload(collection); err != nil {
+	// Once started, the offload is bounded by the lifecycle manager's
+	// shutdown deadline rather than by ctx
+	if err := s.lifecycleMgr.Offload(context.WithoutCancel(ctx), collection); err != nil {
+		// Offload has already moved the collection back to HOT or to FAILED
 		log.Println("offload failed:", collection, err)
-		// fallback: revert state
-		s.store.Set(collection, state.Hot)
 	}
 }
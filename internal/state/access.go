@@ -0,0 +1,113 @@
+package state
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AccessBucket counts the requests to a collection within one hour.
+type AccessBucket struct {
+	Hour time.Time
+	Hits int64
+}
+
+type accessKey struct {
+	collection string
+	hour       time.Time
+}
+
+// RecordAccessHistory starts counting hits per collection and hour. Hits
+// are only counted in memory on the request path; FlushAccessHistory
+// writes them to access_history.
+func (s *Store) RecordAccessHistory() {
+	s.accessMu.Lock()
+	defer s.accessMu.Unlock()
+	if s.pending == nil {
+		s.pending = make(map[accessKey]int64)
+	}
+}
+
+// recordAccess counts a hit towards the collection's hourly access
+// history, if it is recorded.
+func (s *Store) recordAccess(collection string, at time.Time) {
+	s.accessMu.Lock()
+	defer s.accessMu.Unlock()
+	if s.pending != nil {
+		s.pending[accessKey{collection, at.UTC().Truncate(time.Hour)}]++
+	}
+}
+
+// FlushAccessHistory writes the hits counted since the last flush in one
+// transaction.
+func (s *Store) FlushAccessHistory() error {
+	s.accessMu.Lock()
+	pending := s.pending
+	if len(pending) > 0 {
+		s.pending = make(map[accessKey]int64)
+	}
+	s.accessMu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for k, hits := range pending {
+		if _, err := tx.Exec(`
+			INSERT INTO access_history(collection, hour, hits)
+			VALUES (?, ?, ?)
+			ON CONFLICT(collection, hour)
+			DO UPDATE SET hits = hits + excluded.hits
+		`, k.collection, k.hour, hits); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AccessHistory returns the hourly buckets with hits since the given time,
+// oldest first.
+func (s *Store) AccessHistory(collection string, since time.Time) ([]AccessBucket, error) {
+	rows, err := s.db.Query(`
+		SELECT hour, hits
+		FROM access_history
+		WHERE collection = ? AND hour >= ?
+		ORDER BY hour
+	`, collection, since.UTC().Truncate(time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []AccessBucket
+	for rows.Next() {
+		var (
+			b    AccessBucket
+			hour sql.NullTime
+		)
+		if err := rows.Scan(&hour, &b.Hits); err != nil {
+			return nil, err
+		}
+		b.Hour = hour.Time
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// PruneAccessHistory drops buckets older than the given time.
+func (s *Store) PruneAccessHistory(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		DELETE FROM access_history WHERE hour < ?
+	`, before.UTC().Truncate(time.Hour))
+	return err
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Failure describes why a collection is FAILED.
+type Failure struct {
+	// Op is the lifecycle operation that failed ("reload" or "offload").
+	Op       string    `json:"op"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	At       time.Time `json:"at"`
+}
+
+// Fail moves the collection from the given state to FAILED, recording the
+// error and counting the attempt. Attempts accumulate until ClearFailure
+// is called.
+func (s *Store) Fail(collection string, from State, op string, cause error) error {
+	if !CanTransition(from, Failed) {
+		return &TransitionError{Collection: collection, From: from, To: Failed, Current: from, Err: ErrIllegalTransition}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		UPDATE collection_state
+		SET state = ?,
+			last_error = ?,
+			failed_op = ?,
+			attempts = attempts + 1,
+			failed_at = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE collection = ? AND state = ?
+	`, string(Failed), cause.Error(), op, time.Now().UTC(), collection, string(from))
+	return s.checkSwapped(res, err, collection, from, Failed)
+}
+
+// GetFailure returns the last recorded failure, or nil if there is none.
+func (s *Store) GetFailure(collection string) *Failure {
+	var (
+		op, msg sql.NullString
+		at      sql.NullTime
+		f       Failure
+	)
+	err := s.db.QueryRow(`
+		SELECT failed_op, last_error, attempts, failed_at
+		FROM collection_state
+		WHERE collection = ?
+	`, collection).Scan(&op, &msg, &f.Attempts, &at)
+
+	if err != nil || !op.Valid {
+		return nil
+	}
+
+	f.Op = op.String
+	f.Error = msg.String
+	f.At = at.Time
+	return &f
+}
+
+// ClearFailure forgets the recorded failure once an operation succeeds or
+// an operator resets the collection.
+func (s *Store) ClearFailure(collection string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`
+		UPDATE collection_state
+		SET last_error = NULL, failed_op = NULL, attempts = 0, failed_at = NULL
+		WHERE collection = ?
+	`, collection); err != nil {
+		log.Println("Unable to clear failure in sqlite", err)
+	}
+}
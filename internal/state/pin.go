@@ -0,0 +1,110 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrUnknownCollection is returned for collections state.db does not track.
+var ErrUnknownCollection = errors.New("unknown collection")
+
+// Pin exempts the collection from offloading until the given time, or
+// indefinitely if until is zero. Pinning again replaces the expiry.
+func (s *Store) Pin(collection string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expiry any
+	if !until.IsZero() {
+		expiry = until.UTC()
+	}
+
+	res, err := s.db.Exec(`
+		UPDATE collection_state
+		SET pinned = 1, pinned_until = ?
+		WHERE collection = ?
+	`, expiry, collection)
+	return checkTracked(res, err)
+}
+
+// Protect exempts the collection from offloading until at least the given
+// time, e.g. after a pre-warm. Protection is kept apart from operator pins:
+// it never shortens an existing protection and is left alone by Unpin.
+func (s *Store) Protect(collection string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until = until.UTC()
+	_, err := s.db.Exec(`
+		UPDATE collection_state
+		SET protected_until = ?
+		WHERE collection = ?
+		  AND (protected_until IS NULL OR protected_until < ?)
+	`, until, collection, until)
+	return err
+}
+
+func (s *Store) Unpin(collection string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		UPDATE collection_state
+		SET pinned = 0, pinned_until = NULL
+		WHERE collection = ?
+	`, collection)
+	return checkTracked(res, err)
+}
+
+// Pinned reports whether the collection is pinned right now. until is
+// zero for pins without an expiry.
+func (s *Store) Pinned(collection string) (pinned bool, until time.Time) {
+	var (
+		p  bool
+		at sql.NullTime
+	)
+	err := s.db.QueryRow(`
+		SELECT pinned, pinned_until
+		FROM collection_state
+		WHERE collection = ?
+	`, collection).Scan(&p, &at)
+
+	if err != nil || !p {
+		return false, time.Time{}
+	}
+	if at.Valid && !at.Time.After(time.Now()) {
+		return false, time.Time{}
+	}
+	return true, at.Time
+}
+
+// ProtectedUntil returns the end of the collection's protection, or zero if
+// it is not protected right now.
+func (s *Store) ProtectedUntil(collection string) time.Time {
+	var at sql.NullTime
+	err := s.db.QueryRow(`
+		SELECT protected_until
+		FROM collection_state
+		WHERE collection = ?
+	`, collection).Scan(&at)
+
+	if err != nil || !at.Valid || !at.Time.After(time.Now()) {
+		return time.Time{}
+	}
+	return at.Time
+}
+
+func checkTracked(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUnknownCollection
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package state
+
+import "database/sql"
+
+// Placement returns the engine instance a collection is loaded on, or ""
+// if none is recorded.
+func (s *Store) Placement(collection string) string {
+	var instance sql.NullString
+	_ = s.db.QueryRow(
+		`SELECT instance FROM collection_state WHERE collection = ?`,
+		collection,
+	).Scan(&instance)
+
+	return instance.String
+}
+
+// SetPlacement records the engine instance a collection is loaded on. An
+// empty instance clears the placement. Placing a collection state.db does
+// not track fails with ErrUnknownCollection rather than go unrecorded.
+func (s *Store) SetPlacement(collection, instance string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		UPDATE collection_state
+		SET instance = NULLIF(?, '')
+		WHERE collection = ?
+	`, instance, collection)
+	if instance == "" {
+		// Nothing to record for a collection that is not tracked
+		return err
+	}
+	return checkTracked(res, err)
+}
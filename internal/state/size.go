@@ -0,0 +1,79 @@
+package state
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Usage is a HOT collection as seen by memory-pressure eviction.
+type Usage struct {
+	Collection     string
+	LastAccessedAt time.Time
+	// SizeBytes is the estimated memory the collection takes in the engine.
+	SizeBytes int64
+	Pinned    bool
+	Protected bool
+	// Instance is the engine instance the collection is placed on, if any.
+	Instance string
+}
+
+// SetSize records the estimated memory footprint of a collection.
+func (s *Store) SetSize(collection string, bytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`
+		UPDATE collection_state
+		SET size_bytes = ?
+		WHERE collection = ?
+	`, bytes, collection); err != nil {
+		log.Printf("SetSize failed for %s: %v", collection, err)
+	}
+}
+
+// ListHotLRU returns HOT collections, least recently used first.
+// Collections never accessed sort before all others.
+func (s *Store) ListHotLRU() ([]Usage, error) {
+	now := time.Now().UTC()
+	rows, err := s.db.Query(`
+		SELECT collection, last_accessed_at, size_bytes,
+			pinned = 1 AND (pinned_until IS NULL OR pinned_until > ?),
+			protected_until IS NOT NULL AND protected_until > ?,
+			COALESCE(instance, '')
+		FROM collection_state
+		WHERE state = 'HOT'
+		ORDER BY last_accessed_at IS NOT NULL, last_accessed_at, collection
+	`, now, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Usage
+	for rows.Next() {
+		var (
+			u  Usage
+			at sql.NullTime
+		)
+		if err := rows.Scan(&u.Collection, &at, &u.SizeBytes, &u.Pinned, &u.Protected, &u.Instance); err != nil {
+			return nil, err
+		}
+		u.LastAccessedAt = at.Time
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// TotalSize sums the estimated sizes of the collections in a state that
+// are placed on the given instance, or of all of them if instance is "".
+func (s *Store) TotalSize(st State, instance string) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(size_bytes), 0)
+		FROM collection_state
+		WHERE state = ?
+		  AND (? = '' OR instance = ?)
+	`, string(st), instance, instance).Scan(&total)
+	return total, err
+}
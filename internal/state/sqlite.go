@@ -13,6 +13,11 @@ import (
 type Store struct {
 	db *sql.DB
 	mu sync.Mutex
+
+	// accessMu guards pending, the hits not yet written to access_history;
+	// pending is nil while access history is not recorded
+	accessMu sync.Mutex
+	pending  map[accessKey]int64
 }
 
 func NewSQLite(path string) (*Store, error) {
@@ -42,7 +47,7 @@ func (s *Store) Touch(collection string) {
 
 	now := time.Now().UTC()
 
-	_, err := s.db.Exec(`
+	res, err := s.db.Exec(`
 		UPDATE collection_state
 		SET last_accessed_at = ?
 		WHERE collection = ?
@@ -50,6 +55,10 @@ func (s *Store) Touch(collection string) {
 
 	if err != nil {
 		log.Printf("Touch failed for %s: %v", collection, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		s.recordAccess(collection, now)
 	}
 }
 
@@ -78,30 +87,75 @@ func (s *Store) Get(collection string) State {
 	return State(st)
 }
 
-func (s *Store) Set(collection string, state State) {
+// Register starts tracking a collection in the given state, counting it
+// as accessed now so it is not offloaded straight away. It does nothing if
+// the collection is already known, and reports whether it was added.
+func (s *Store) Register(collection string, state State) (bool, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, err := s.db.Exec(`
-		INSERT INTO collection_state(collection, state)
-		VALUES (?, ?)
-		ON CONFLICT(collection)
-		DO UPDATE SET state=excluded.state, updated_at=CURRENT_TIMESTAMP
-	`, collection, string(state)); err != nil {
-		log.Println("Unable to set in sqlite", err)
+	res, err := s.db.Exec(`
+		INSERT INTO collection_state(collection, state, last_accessed_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(collection) DO NOTHING
+	`, collection, string(state), time.Now().UTC())
+	if err != nil {
+		return false, err
 	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Transition moves the collection from one state to another. The edge must
+// be in the transition table and the collection must currently be in from;
+// the check and the update happen in one statement, so concurrent callers
+// racing for the same edge see exactly one winner.
+func (s *Store) Transition(collection string, from, to State) error {
+	if !CanTransition(from, to) {
+		return &TransitionError{Collection: collection, From: from, To: to, Current: from, Err: ErrIllegalTransition}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	res, err := s.db.Exec(`
+		UPDATE collection_state
+		SET state = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE collection = ? AND state = ?
+	`, string(to), collection, string(from))
+	return s.checkSwapped(res, err, collection, from, to)
+}
+
+// checkSwapped turns a compare-and-swap UPDATE that matched no row into a
+// conflict error. Callers hold s.mu.
+func (s *Store) checkSwapped(res sql.Result, err error, collection string, from, to State) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &TransitionError{Collection: collection, From: from, To: to, Current: s.Get(collection), Err: ErrStateConflict}
+	}
+	return nil
 }
 
+// ListHotOlderThan returns the HOT collections idle for longer than d,
+// leaving out pinned and protected ones.
 func (s *Store) ListHotOlderThan(d time.Duration) []string {
 	seconds := int64(d.Seconds())
+	now := time.Now().UTC()
 	rows, err := s.db.Query(`
     SELECT collection
     FROM collection_state
     WHERE state = 'HOT'
       AND last_accessed_at IS NOT NULL
       AND last_accessed_at < DATETIME('now', ?)
-`, fmt.Sprintf("-%d seconds", seconds))
+      AND NOT (pinned = 1 AND (pinned_until IS NULL OR pinned_until > ?))
+      AND NOT (protected_until IS NOT NULL AND protected_until > ?)
+`, fmt.Sprintf("-%d seconds", seconds), now, now)
 	if err != nil {
 		return nil
 	}
@@ -113,16 +167,52 @@ func (s *Store) ListHotOlderThan(d time.Duration) []string {
 		rows.Scan(&c)
 		out = append(out, c)
 	}
-	// fmt.Println(`SELECT collection
-	// 	FROM collection_state
-	// 	WHERE state = 'HOT'
-	// 	AND last_accessed_at IS NOT NULL
-	// 	AND last_accessed_at < DATETIME('now', ?)
-	// `, "-"+d.String())
-	fmt.Println(out)
 	return out
 }
 
+// List returns the state of every tracked collection.
+func (s *Store) List() (map[string]State, error) {
+	rows, err := s.db.Query(`SELECT collection, state FROM collection_state`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]State)
+	for rows.Next() {
+		var c, st string
+		if err := rows.Scan(&c, &st); err != nil {
+			return nil, err
+		}
+		out[c] = State(st)
+	}
+	return out, rows.Err()
+}
+
+// ListByState returns every collection currently in the given state.
+func (s *Store) ListByState(st State) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT collection
+		FROM collection_state
+		WHERE state = ?
+		ORDER BY collection
+	`, string(st))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
 func (s *Store) WasRecentlyAccessed(collection string, d time.Duration) bool {
 	var count int
 	cutoff := time.Now().UTC().Add(-d)
@@ -150,10 +240,70 @@ func (s *Store) initSchema() error {
 		last_accessed_at DATETIME,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
+
+	CREATE TABLE IF NOT EXISTS access_history (
+		collection TEXT NOT NULL,
+		hour DATETIME NOT NULL,
+		hits INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (collection, hour)
+	);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+	return s.migrate()
+}
+
+// columns added after the initial schema, applied to existing databases
+// in order.
+var migrations = []struct {
+	column     string
+	definition string
+}{
+	{"last_error", "TEXT"},
+	{"failed_op", "TEXT"},
+	{"attempts", "INTEGER NOT NULL DEFAULT 0"},
+	{"failed_at", "DATETIME"},
+	{"pinned", "INTEGER NOT NULL DEFAULT 0"},
+	{"pinned_until", "DATETIME"},
+	{"size_bytes", "INTEGER NOT NULL DEFAULT 0"},
+	{"instance", "TEXT"},
+	{"protected_until", "DATETIME"},
+}
+
+func (s *Store) migrate() error {
+	rows, err := s.db.Query(`PRAGMA table_info(collection_state)`)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			def              sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &def, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, m := range migrations {
+		if existing[m.column] {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf(
+			`ALTER TABLE collection_state ADD COLUMN %s %s`, m.column, m.definition,
+		)); err != nil {
+			return fmt.Errorf("migrate column %s: %w", m.column, err)
+		}
+	}
+	return nil
 }
 
 func (s *Store) CountByState() map[State]int {
@@ -10,6 +10,7 @@ const (
 	Draining State = "DRAINING"
 	Cold     State = "COLD"
 	Loading  State = "LOADING"
+	Failed   State = "FAILED"
 )
 
 // 	_ "github.com/mattn/go-sqlite3"
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrIllegalTransition means the edge is not in the transition table.
+	ErrIllegalTransition = errors.New("illegal state transition")
+	// ErrStateConflict means the collection was not in the expected state,
+	// usually because another goroutine moved it first.
+	ErrStateConflict = errors.New("collection state changed concurrently")
+)
+
+// transitions lists every legal edge of the lifecycle state machine.
+var transitions = map[State][]State{
+	// HOT → COLD is taken by the reconciler when a collection was deleted
+	// from the engine behind our back but still has a snapshot.
+	Hot:      {Draining, Cold},
+	Draining: {Hot, Cold, Failed},
+	Cold:     {Loading},
+	// LOADING → COLD rolls back a reload interrupted by a restart.
+	Loading: {Hot, Cold, Failed},
+	// FAILED is left by an operator: retry re-enters COLD or DRAINING,
+	// reset settles on HOT or COLD.
+	Failed: {Hot, Cold, Draining},
+}
+
+// CanTransition reports whether from → to is a legal edge.
+func CanTransition(from, to State) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// TransitionError is returned when a transition is refused. It matches
+// ErrIllegalTransition or ErrStateConflict with errors.Is.
+type TransitionError struct {
+	Collection string
+	From, To   State
+	// Current is the state found in the store; empty if the collection is unknown.
+	Current State
+	Err     error
+}
+
+func (e *TransitionError) Error() string {
+	if e.Err == ErrIllegalTransition {
+		return fmt.Sprintf("%s: %s → %s: %v", e.Collection, e.From, e.To, e.Err)
+	}
+	current := e.Current
+	if current == "" {
+		current = "unknown"
+	}
+	return fmt.Sprintf("%s: %s → %s: collection is %s: %v", e.Collection, e.From, e.To, current, e.Err)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
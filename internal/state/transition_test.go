@@ -0,0 +1,88 @@
+package state
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanTransition(t *testing.T) {
+	states := []State{Hot, Draining, Cold, Loading, Failed}
+	legal := map[[2]State]bool{
+		{Hot, Draining}:    true,
+		{Hot, Cold}:        true,
+		{Draining, Hot}:    true,
+		{Draining, Cold}:   true,
+		{Draining, Failed}: true,
+		{Cold, Loading}:    true,
+		{Loading, Hot}:     true,
+		{Loading, Cold}:    true,
+		{Loading, Failed}:  true,
+		{Failed, Hot}:      true,
+		{Failed, Cold}:     true,
+		{Failed, Draining}: true,
+	}
+
+	for _, from := range states {
+		for _, to := range states {
+			want := legal[[2]State{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+	if CanTransition("", Hot) || CanTransition(Hot, "") {
+		t.Error("transitions from or to an unknown state are allowed")
+	}
+}
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  State
+		from, to State
+		want     error
+		// after is the state the collection is left in
+		after State
+	}{
+		{"legal", Hot, Hot, Draining, nil, Draining},
+		{"illegal edge", Cold, Cold, Hot, ErrIllegalTransition, Cold},
+		{"stale from", Loading, Cold, Loading, ErrStateConflict, Loading},
+		{"failed to retry", Failed, Failed, Draining, nil, Draining},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSQLite(filepath.Join(t.TempDir(), "state.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Register("products", tt.current); err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.Transition("products", tt.from, tt.to)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("Transition error = %v, want %v", err, tt.want)
+			}
+			var te *TransitionError
+			if tt.want != nil && (!errors.As(err, &te) || te.Current != tt.current) {
+				t.Errorf("Transition error = %#v, want a TransitionError with current state %s", err, tt.current)
+			}
+			if got := s.Get("products"); got != tt.after {
+				t.Errorf("state = %s, want %s", got, tt.after)
+			}
+		})
+	}
+}
+
+func TestTransitionUnknownCollection(t *testing.T) {
+	s, err := NewSQLite(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Transition("missing", Cold, Loading)
+	var te *TransitionError
+	if !errors.As(err, &te) || !errors.Is(err, ErrStateConflict) || te.Current != "" {
+		t.Errorf("Transition error = %v, want a conflict with no current state", err)
+	}
+}
@@ -0,0 +1,5 @@
+package version
+
+// Version is the Hiberstack release, set at build time with
+// -ldflags "-X github.com/SoyebSarkar/Hiberstack/internal/version.Version=v0.x.y".
+var Version = "dev"
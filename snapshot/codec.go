@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec is the compression applied to documents.jsonl.
+type Codec string
+
+const (
+	CodecNone Codec = "none"
+	CodecGzip Codec = "gzip"
+	CodecZstd Codec = "zstd"
+)
+
+func ParseCodec(s string) (Codec, error) {
+	switch c := Codec(s); c {
+	case CodecNone, CodecGzip, CodecZstd:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unknown compression %q", s)
+	}
+}
+
+// documentsName is the file the codec stores documents under.
+func (c Codec) documentsName() string {
+	switch c {
+	case CodecGzip:
+		return documentsFile + ".gz"
+	case CodecZstd:
+		return documentsFile + ".zst"
+	default:
+		return documentsFile
+	}
+}
+
+func (c Codec) compress(w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case CodecGzip:
+		return gzip.NewWriter(w), nil
+	case CodecZstd:
+		return zstd.NewWriter(w)
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+func (c Codec) decompress(r io.Reader) (io.ReadCloser, error) {
+	switch c {
+	case CodecGzip:
+		return gzip.NewReader(r)
+	case CodecZstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return io.NopCloser(r), nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
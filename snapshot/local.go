@@ -0,0 +1,217 @@
+package snapshot
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	stagingInfix   = ".staging-"
+	previousSuffix = ".previous"
+)
+
+// Local keeps snapshots in {Root}/{collection}/ on the local filesystem.
+type Local struct {
+	Root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{Root: root}
+}
+
+// Create stages the new snapshot in a sibling directory. Nothing under the
+// live snapshot changes until Commit, so a crash mid-export leaves the
+// previous snapshot intact and only an orphaned staging directory behind.
+func (l *Local) Create(collection string) (Sink, error) {
+	if err := os.MkdirAll(l.Root, 0755); err != nil {
+		return nil, err
+	}
+
+	stageDir, err := os.MkdirTemp(l.Root, collection+stagingInfix)
+	if err != nil {
+		return nil, err
+	}
+	return &localSink{
+		baseDir:  filepath.Join(l.Root, collection),
+		stageDir: stageDir,
+		dirs:     map[string]bool{stageDir: true},
+	}, nil
+}
+
+// Open holds on to the snapshot directory itself, so a Commit that swaps
+// in a new one does not change what is read through it.
+func (l *Local) Open(collection string) (Snapshot, error) {
+	root, err := os.OpenRoot(filepath.Join(l.Root, collection))
+	if err != nil {
+		return nil, err
+	}
+	return &localSnapshot{root}, nil
+}
+
+func (l *Local) Exists(collection string) (bool, error) {
+	info, err := os.Stat(filepath.Join(l.Root, collection))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+func (l *Local) List() ([]string, error) {
+	entries, err := os.ReadDir(l.Root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.Contains(name, stagingInfix) || strings.HasSuffix(name, previousSuffix) {
+			continue
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// Recover discards staging directories and puts back a snapshot left
+// moved aside by an interrupted Commit.
+func (l *Local) Recover() error {
+	entries, err := os.ReadDir(l.Root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		path := filepath.Join(l.Root, name)
+
+		switch {
+		case strings.Contains(name, stagingInfix):
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+
+		case strings.HasSuffix(name, previousSuffix):
+			live := strings.TrimSuffix(path, previousSuffix)
+			if _, err := os.Stat(live); os.IsNotExist(err) {
+				if err := os.Rename(path, live); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+	}
+	return syncDir(l.Root)
+}
+
+type localSnapshot struct {
+	root *os.Root
+}
+
+func (s *localSnapshot) Open(name string) (io.ReadCloser, error) {
+	return s.root.Open(filepath.FromSlash(name))
+}
+
+func (s *localSnapshot) Close() error {
+	return s.root.Close()
+}
+
+type localSink struct {
+	baseDir  string
+	stageDir string
+	dirs     map[string]bool
+}
+
+func (s *localSink) Create(name string) (io.WriteCloser, error) {
+	path := filepath.Join(s.stageDir, filepath.FromSlash(name))
+
+	dir := filepath.Dir(path)
+	if !s.dirs[dir] {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		s.dirs[dir] = true
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &syncedFile{f}, nil
+}
+
+// Commit swaps the staged snapshot into place. The live snapshot is first
+// moved aside to {collection}.previous so that a crash between the two
+// renames can be rolled back by Recover.
+func (s *localSink) Commit() error {
+	for dir := range s.dirs {
+		if err := syncDir(dir); err != nil {
+			return err
+		}
+	}
+
+	previous := s.baseDir + previousSuffix
+	if err := os.RemoveAll(previous); err != nil {
+		return err
+	}
+
+	hadPrevious := true
+	if err := os.Rename(s.baseDir, previous); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		hadPrevious = false
+	}
+
+	if err := os.Rename(s.stageDir, s.baseDir); err != nil {
+		if hadPrevious {
+			os.Rename(previous, s.baseDir)
+		}
+		return err
+	}
+
+	if err := syncDir(filepath.Dir(s.baseDir)); err != nil {
+		return err
+	}
+	return os.RemoveAll(previous)
+}
+
+func (s *localSink) Abort() error {
+	return os.RemoveAll(s.stageDir)
+}
+
+// syncedFile fsyncs before closing so a committed snapshot survives a crash.
+type syncedFile struct {
+	*os.File
+}
+
+func (f *syncedFile) Close() error {
+	if err := f.File.Sync(); err != nil {
+		f.File.Close()
+		return err
+	}
+	return f.File.Close()
+}
+
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
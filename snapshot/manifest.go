@@ -0,0 +1,210 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/SoyebSarkar/Hiberstack/internal/version"
+)
+
+const (
+	manifestFile   = "metadata.json"
+	manifestFormat = 1
+)
+
+// ErrCorrupt is wrapped by every verification failure so callers can tell
+// a damaged snapshot apart from an I/O error.
+var ErrCorrupt = errors.New("snapshot corrupt")
+
+// Source identifies the engine a snapshot was exported from.
+type Source struct {
+	Engine        string
+	EngineVersion string
+}
+
+// Manifest is written to metadata.json when a snapshot is committed.
+type Manifest struct {
+	Format            int                 `json:"format"`
+	Collection        string              `json:"collection"`
+	Engine            string              `json:"engine"`
+	EngineVersion     string              `json:"engine_version"`
+	HiberstackVersion string              `json:"hiberstack_version"`
+	OffloadedAt       time.Time           `json:"offloaded_at"`
+	DocumentCount     int64               `json:"document_count"`
+	DocumentBytes     int64               `json:"document_bytes"`
+	Compression       Codec               `json:"compression"`
+	SchemaSHA256      string              `json:"schema_sha256"`
+	Files             map[string]FileInfo `json:"files"`
+}
+
+type FileInfo struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func (w *Writer) writeManifest() error {
+	m := Manifest{
+		Format:            manifestFormat,
+		Collection:        w.collection,
+		Engine:            w.source.Engine,
+		EngineVersion:     w.source.EngineVersion,
+		HiberstackVersion: version.Version,
+		OffloadedAt:       time.Now().UTC(),
+		DocumentCount:     w.documentCount,
+		DocumentBytes:     w.documentBytes,
+		Compression:       w.codec,
+		SchemaSHA256:      w.files[schemaFile].SHA256,
+		Files:             w.files,
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := w.sink.Create(manifestFile)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readManifest loads metadata.json. Snapshots written before manifests
+// existed return a nil manifest and no error.
+func readManifest(snap Snapshot) (*Manifest, error) {
+	f, err := snap.Open(manifestFile)
+	if isNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: unreadable %s: %v", ErrCorrupt, manifestFile, err)
+	}
+	return &m, nil
+}
+
+// Verify re-hashes every file listed in the manifest and reports the first
+// file that is missing or does not match. A nil manifest is returned for
+// legacy snapshots, which cannot be verified.
+func Verify(store Store, collection string) (*Manifest, error) {
+	r, err := OpenReader(store, collection)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if err := r.Verify(); err != nil {
+		return nil, err
+	}
+	return r.Manifest(), nil
+}
+
+// verifiedFile checks a file against its manifest entry as it is read. The
+// end of the file is only reported once its size and checksum match.
+type verifiedFile struct {
+	io.ReadCloser
+	name string
+	want FileInfo
+	hash hash.Hash
+	size int64
+	// err is what every read returns once the file has been checked
+	err error
+}
+
+func newVerifiedFile(f io.ReadCloser, name string, want FileInfo) *verifiedFile {
+	return &verifiedFile{ReadCloser: f, name: name, want: want, hash: sha256.New()}
+}
+
+func (f *verifiedFile) Read(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+
+	n, err := f.ReadCloser.Read(p)
+	f.hash.Write(p[:n])
+	f.size += int64(n)
+
+	switch {
+	case f.size > f.want.Size:
+		f.err = fmt.Errorf("%w: %s is over %d bytes, the size in the manifest", ErrCorrupt, f.name, f.want.Size)
+	case err == io.EOF:
+		f.err = f.check()
+	case err != nil:
+		return n, err
+	}
+	if f.err != nil {
+		return n, f.err
+	}
+	return n, nil
+}
+
+// verified reports whether the whole file was read and matched.
+func (f *verifiedFile) verified() bool {
+	return f.err == io.EOF
+}
+
+// check compares the file with the manifest once it has been read, and
+// returns io.EOF if it matches.
+func (f *verifiedFile) check() error {
+	if f.size != f.want.Size {
+		return fmt.Errorf("%w: %s is %d bytes, manifest says %d", ErrCorrupt, f.name, f.size, f.want.Size)
+	}
+	if got := hex.EncodeToString(f.hash.Sum(nil)); got != f.want.SHA256 {
+		return fmt.Errorf("%w: %s sha256 %s, manifest says %s", ErrCorrupt, f.name, got, f.want.SHA256)
+	}
+	return io.EOF
+}
+
+// lineCounter counts JSONL documents as they are written. Blank lines,
+// including one left by a trailing newline, are not documents.
+type lineCounter struct {
+	lines int64
+	// inLine is set once the current line has more than whitespace
+	inLine bool
+	bytes  int64
+}
+
+func (c *lineCounter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		switch b {
+		case '\n':
+			if c.inLine {
+				c.lines++
+			}
+			c.inLine = false
+		case ' ', '\t', '\r':
+		default:
+			c.inLine = true
+		}
+	}
+	c.bytes += int64(len(p))
+	return len(p), nil
+}
+
+// count includes a final document that is missing its trailing newline.
+func (c *lineCounter) count() int64 {
+	if c.inLine {
+		return c.lines + 1
+	}
+	return c.lines
+}
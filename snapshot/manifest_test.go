@@ -0,0 +1,35 @@
+package snapshot
+
+import "testing"
+
+func TestLineCounter(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   int64
+	}{
+		{"empty", nil, 0},
+		{"trailing newline", []string{"{}\n{}\n"}, 2},
+		{"no trailing newline", []string{"{}\n{}"}, 2},
+		{"blank lines", []string{"\n{}\n\n\n{}\n\n"}, 2},
+		{"whitespace lines", []string{"{}\r\n  \r\n\t\n{}\r\n"}, 2},
+		{"only newlines", []string{"\n\n\n"}, 0},
+		{"split across writes", []string{"{\"id\"", ":1}\n", "\n", "{}"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c lineCounter
+			var size int64
+			for _, chunk := range tt.chunks {
+				c.Write([]byte(chunk))
+				size += int64(len(chunk))
+			}
+			if got := c.count(); got != tt.want {
+				t.Errorf("count = %d, want %d", got, tt.want)
+			}
+			if c.bytes != size {
+				t.Errorf("bytes = %d, want %d", c.bytes, size)
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an in-memory stand-in for the parts of the S3 API the store
+// uses, with path-style addressing.
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	// uploads holds the parts of multipart uploads in progress by id
+	uploads  map[string]map[int][]byte
+	nextID   int
+	aborted  []string
+	complete []string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{
+		bucket:  "snapshots",
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[int][]byte),
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"+f.bucket), "/")
+	q := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+	_, initiate := q["uploads"]
+	uploadID := q.Get("uploadId")
+
+	switch {
+	case r.Method == "GET" && key == "":
+		f.list(w, q.Get("prefix"), q.Get("delimiter"))
+
+	case r.Method == "GET":
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+
+	case r.Method == "POST" && initiate:
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[id] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+
+	case r.Method == "PUT" && uploadID != "":
+		parts, ok := f.uploads[uploadID]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchUpload</Code></Error>", http.StatusNotFound)
+			return
+		}
+		var n int
+		fmt.Sscan(q.Get("partNumber"), &n)
+		parts[n] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, n))
+
+	case r.Method == "POST" && uploadID != "":
+		parts, ok := f.uploads[uploadID]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchUpload</Code></Error>", http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Parts []s3Part `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var data []byte
+		for i, p := range req.Parts {
+			if p.PartNumber != i+1 || p.ETag != fmt.Sprintf(`"etag-%d"`, i+1) {
+				w.Write([]byte("<Error><Code>InvalidPart</Code><Message>bad part list</Message></Error>"))
+				return
+			}
+			data = append(data, parts[p.PartNumber]...)
+		}
+		f.objects[key] = data
+		delete(f.uploads, uploadID)
+		f.complete = append(f.complete, key)
+		w.Write([]byte("<CompleteMultipartUploadResult></CompleteMultipartUploadResult>"))
+
+	case r.Method == "DELETE" && uploadID != "":
+		if _, ok := f.uploads[uploadID]; !ok {
+			http.Error(w, "<Error><Code>NoSuchUpload</Code></Error>", http.StatusNotFound)
+			return
+		}
+		delete(f.uploads, uploadID)
+		f.aborted = append(f.aborted, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == "PUT":
+		f.objects[key] = body
+
+	case r.Method == "DELETE":
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	var res s3ListResult
+	seen := make(map[string]bool)
+	for _, key := range f.keys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(rest, delimiter); delimiter != "" && i >= 0 {
+			p := prefix + rest[:i+1]
+			if !seen[p] {
+				seen[p] = true
+				res.CommonPrefixes = append(res.CommonPrefixes, struct {
+					Prefix string `xml:"Prefix"`
+				}{p})
+			}
+			continue
+		}
+		res.Contents = append(res.Contents, struct {
+			Key string `xml:"Key"`
+		}{key})
+	}
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"ListBucketResult"`
+		s3ListResult
+	}{s3ListResult: res})
+}
+
+func (f *fakeS3) keys() []string {
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *fakeS3) snapshotKeys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.keys()
+}
+
+func newTestS3(t *testing.T, srv *httptest.Server) *S3 {
+	t.Helper()
+	s, err := NewS3(S3Config{
+		Endpoint:        srv.URL,
+		Bucket:          "snapshots",
+		Prefix:          "hiberstack",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+		PartSize:        MinS3PartSize,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func writeFile(t *testing.T, sink Sink, name string, data []byte) {
+	t.Helper()
+	w, err := sink.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, s Store, collection, name string) []byte {
+	t.Helper()
+	snap, err := s.Open(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Close()
+	r, err := snap.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestS3MultipartUpload(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	s := newTestS3(t, srv)
+
+	// Two full parts and a short last one
+	large := bytes.Repeat([]byte("0123456789abcdef"), (2*MinS3PartSize+1024)/16)
+	sink, err := s.Create("products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, sink, "documents.jsonl", large)
+	writeFile(t, sink, "schema.json", []byte(`{"name":"products"}`))
+
+	if ok, _ := s.Exists("products"); ok {
+		t.Error("snapshot visible before commit")
+	}
+	if err := sink.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.complete) != 1 || !strings.HasSuffix(fake.complete[0], "/documents.jsonl") {
+		t.Errorf("completed uploads = %v, want documents.jsonl only", fake.complete)
+	}
+	if got := readFile(t, s, "products", "documents.jsonl"); !bytes.Equal(got, large) {
+		t.Errorf("read back %d bytes, want %d", len(got), len(large))
+	}
+	if got := readFile(t, s, "products", "schema.json"); string(got) != `{"name":"products"}` {
+		t.Errorf("schema = %s", got)
+	}
+
+	collections, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(collections, []string{"products"}) {
+		t.Errorf("List = %v, want [products]", collections)
+	}
+}
+
+func TestS3CommitSwapsCurrent(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	s := newTestS3(t, srv)
+
+	first, _ := s.Create("products")
+	writeFile(t, first, "schema.json", []byte("v1"))
+	if err := first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	second, _ := s.Create("products")
+	writeFile(t, second, "schema.json", []byte("v2"))
+	// Readers see the old snapshot until the new one is committed
+	if got := readFile(t, s, "products", "schema.json"); string(got) != "v1" {
+		t.Errorf("before commit read %q, want v1", got)
+	}
+	if err := second.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, s, "products", "schema.json"); string(got) != "v2" {
+		t.Errorf("after commit read %q, want v2", got)
+	}
+
+	gen := second.(*s3Sink).generation
+	want := []string{
+		"hiberstack/products/CURRENT",
+		"hiberstack/products/" + gen + "/schema.json",
+	}
+	sort.Strings(want)
+	if got := fake.snapshotKeys(); !slices.Equal(got, want) {
+		t.Errorf("objects = %v, want the superseded generation removed: %v", got, want)
+	}
+}
+
+func TestS3Abort(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	s := newTestS3(t, srv)
+
+	sink, _ := s.Create("products")
+	writeFile(t, sink, "schema.json", []byte(`{}`))
+
+	// A file cut off mid-upload, with one part already sent
+	w, _ := sink.Create("documents.jsonl")
+	if _, err := w.Write(make([]byte, MinS3PartSize+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.aborted) != 1 || !strings.HasSuffix(fake.aborted[0], "/documents.jsonl") {
+		t.Errorf("aborted uploads = %v, want documents.jsonl", fake.aborted)
+	}
+	if len(fake.uploads) != 0 {
+		t.Errorf("%d uploads left open", len(fake.uploads))
+	}
+	if keys := fake.snapshotKeys(); len(keys) != 0 {
+		t.Errorf("objects left after abort: %v", keys)
+	}
+	if ok, err := s.Exists("products"); ok || err != nil {
+		t.Errorf("Exists = %v, %v after abort", ok, err)
+	}
+}
+
+func TestS3Recover(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	s := newTestS3(t, srv)
+
+	sink, _ := s.Create("products")
+	writeFile(t, sink, "schema.json", []byte("live"))
+	if err := sink.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	gen := sink.(*s3Sink).generation
+
+	// An offload that crashed before committing, for a collection with a
+	// snapshot and for one without
+	fake.objects["hiberstack/products/1/schema.json"] = []byte("partial")
+	fake.objects["hiberstack/orders/2/schema.json"] = []byte("partial")
+
+	if err := s.Recover(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"hiberstack/products/" + gen + "/schema.json",
+		"hiberstack/products/CURRENT",
+	}
+	sort.Strings(want)
+	if got := fake.snapshotKeys(); !slices.Equal(got, want) {
+		t.Errorf("objects after recover = %v, want %v", got, want)
+	}
+	if got := readFile(t, s, "products", "schema.json"); string(got) != "live" {
+		t.Errorf("read %q after recover, want live", got)
+	}
+}
+
+func TestS3NotFound(t *testing.T) {
+	_, srv := newFakeS3(t)
+	s := newTestS3(t, srv)
+
+	if _, err := s.Open("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open error = %v, want fs.ErrNotExist", err)
+	}
+	if ok, err := s.Exists("missing"); ok || err != nil {
+		t.Errorf("Exists = %v, %v, want false, nil", ok, err)
+	}
+
+	sink, _ := s.Create("products")
+	writeFile(t, sink, "schema.json", []byte("{}"))
+	if err := sink.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := s.Open("products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := snap.Open("extras/synonyms.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open of missing file error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestS3Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	s := newTestS3(t, srv)
+	s.cfg.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := s.Exists("products")
+	if err == nil {
+		t.Fatal("Exists succeeded against a hung endpoint")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Exists took %s, want about the 50ms timeout", elapsed)
+	}
+	var timeout interface{ Timeout() bool }
+	if !errors.As(err, &timeout) || !timeout.Timeout() {
+		t.Errorf("error = %v, want a timeout", err)
+	}
+}
@@ -1,31 +1,303 @@
 package snapshot
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"sort"
+	"strings"
 )
 
-func SaveSchema(baseDir string, schema []byte) error {
-	path := filepath.Join(baseDir, "schema.json")
-	os.MkdirAll(filepath.Dir(path), 0755)
+const (
+	schemaFile      = "schema.json"
+	documentsFile   = "documents.jsonl"
+	extrasDirectory = "extras"
+)
+
+// Writer writes one snapshot into a Store, recording the size and checksum
+// of every file for the manifest written on Commit.
+type Writer struct {
+	sink       Sink
+	collection string
+	source     Source
+	codec      Codec
 
-	return os.WriteFile(path, schema, 0644)
+	files         map[string]FileInfo
+	documentCount int64
+	documentBytes int64
 }
 
-func SaveDocuments(baseDir string, r io.Reader) error {
-	path := filepath.Join(baseDir, "documents.jsonl")
+func Begin(store Store, collection string, source Source, codec Codec) (*Writer, error) {
+	sink, err := store.Create(collection)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		sink:       sink,
+		collection: collection,
+		source:     source,
+		codec:      codec,
+		files:      make(map[string]FileInfo),
+	}, nil
+}
 
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
+func (w *Writer) SaveSchema(schema []byte) error {
+	return w.save(schemaFile, func(f io.Writer) error {
+		_, err := f.Write(schema)
+		return err
+	})
+}
+
+// SaveDocuments compresses the export with the writer's codec as it is
+// streamed out. Document count and size refer to the uncompressed JSONL.
+func (w *Writer) SaveDocuments(r io.Reader) error {
+	var counter lineCounter
+	err := w.save(w.codec.documentsName(), func(f io.Writer) error {
+		cw, err := w.codec.compress(f)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.MultiWriter(cw, &counter), r); err != nil {
+			cw.Close()
+			return err
+		}
+		return cw.Close()
+	})
+	w.documentCount = counter.count()
+	w.documentBytes = counter.bytes
+	return err
+}
+
+// DocumentCount is the number of documents written by SaveDocuments.
+func (w *Writer) DocumentCount() int64 {
+	return w.documentCount
+}
+
+// SaveExtras writes each engine-specific extra (synonyms, overrides, ...)
+// to extras/{name}.json inside the snapshot.
+func (w *Writer) SaveExtras(extras map[string][]byte) error {
+	for name, data := range extras {
+		err := w.save(extrasDirectory+"/"+name+".json", func(f io.Writer) error {
+			_, err := f.Write(data)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit writes the manifest and makes the snapshot visible to readers.
+func (w *Writer) Commit() error {
+	if err := w.writeManifest(); err != nil {
 		return err
 	}
+	return w.sink.Commit()
+}
+
+// Abort discards the snapshot being written.
+func (w *Writer) Abort() error {
+	return w.sink.Abort()
+}
 
-	f, err := os.Create(path)
+func (w *Writer) save(name string, write func(f io.Writer) error) error {
+	f, err := w.sink.Create(name)
 	if err != nil {
 		return err
 	}
+
+	h := sha256.New()
+	var counter lineCounter
+	if err := write(io.MultiWriter(f, h, &counter)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	w.files[name] = FileInfo{Size: counter.bytes, SHA256: hex.EncodeToString(h.Sum(nil))}
+	return nil
+}
+
+// Reader reads back one committed snapshot. The snapshot is resolved once,
+// so every file comes from the same one, and each file is checked against
+// the manifest as it is read rather than in a separate pass.
+type Reader struct {
+	snap     Snapshot
+	manifest *Manifest
+}
+
+func OpenReader(store Store, collection string) (*Reader, error) {
+	snap, err := store.Open(collection)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readManifest(snap)
+	if err != nil {
+		snap.Close()
+		return nil, err
+	}
+	return &Reader{snap: snap, manifest: m}, nil
+}
+
+// Manifest is nil for snapshots written before manifests existed; their
+// files are read without verification.
+func (r *Reader) Manifest() *Manifest {
+	return r.manifest
+}
+
+func (r *Reader) Schema() ([]byte, error) {
+	return r.readAll(schemaFile)
+}
+
+// Documents streams the uncompressed JSONL of the snapshot. The codec is
+// taken from the manifest; snapshots without one hold plain documents.jsonl.
+// A mismatch with the manifest fails the read that reaches the end of the
+// file, and Close fails unless the file was read to the end and matched.
+func (r *Reader) Documents() (io.ReadCloser, error) {
+	codec := CodecNone
+	if r.manifest != nil && r.manifest.Compression != "" {
+		codec = r.manifest.Compression
+	}
+
+	f, err := r.open(codec.documentsName())
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := codec.decompress(f)
+	if err != nil {
+		f.Close()
+		if errors.Is(err, ErrCorrupt) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorrupt, codec.documentsName(), err)
+	}
+	return &documentsReader{ReadCloser: d, file: f}, nil
+}
+
+// Extras reads back every extra listed in the manifest. Snapshots without
+// a manifest predate extras and yield an empty map.
+func (r *Reader) Extras() (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	if r.manifest == nil {
+		return out, nil
+	}
+
+	prefix := extrasDirectory + "/"
+	for name := range r.manifest.Files {
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, err := r.readAll(name)
+		if err != nil {
+			return nil, err
+		}
+		out[strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".json")] = data
+	}
+	return out, nil
+}
+
+// Verify reads every file listed in the manifest and reports the first one
+// that is missing or does not match.
+func (r *Reader) Verify() error {
+	if r.manifest == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(r.manifest.Files))
+	for name := range r.manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f, err := r.open(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(io.Discard, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reader) Close() error {
+	return r.snap.Close()
+}
+
+// open returns a file of the snapshot, checked against the manifest as it
+// is read if there is one.
+func (r *Reader) open(name string) (io.ReadCloser, error) {
+	if r.manifest == nil {
+		return r.snap.Open(name)
+	}
+
+	want, ok := r.manifest.Files[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s not in manifest", ErrCorrupt, name)
+	}
+	f, err := r.snap.Open(name)
+	if isNotExist(err) {
+		return nil, fmt.Errorf("%w: %s missing", ErrCorrupt, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newVerifiedFile(f, name, want), nil
+}
+
+func (r *Reader) readAll(name string) ([]byte, error) {
+	f, err := r.open(name)
+	if err != nil {
+		return nil, err
+	}
 	defer f.Close()
+	return io.ReadAll(f)
+}
 
-	_, err = io.Copy(f, r)
-	return err
+// documentsReader closes both the decompressor and the underlying file.
+type documentsReader struct {
+	io.ReadCloser
+	file io.ReadCloser
+	err  error
+}
+
+func (d *documentsReader) Read(p []byte) (int, error) {
+	n, err := d.ReadCloser.Read(p)
+	if err == io.EOF {
+		// The codec may stop short of the end of the file; the rest is
+		// part of the checksum all the same
+		if _, rest := io.Copy(io.Discard, d.file); rest != nil {
+			err = rest
+		}
+	}
+	if err != nil && err != io.EOF && d.err == nil {
+		d.err = err
+	}
+	return n, err
+}
+
+func (d *documentsReader) Close() error {
+	d.ReadCloser.Close()
+	closeErr := d.file.Close()
+	if d.err != nil {
+		return d.err
+	}
+	if f, ok := d.file.(*verifiedFile); ok && !f.verified() {
+		return fmt.Errorf("%s was not read to the end and could not be verified", f.name)
+	}
+	return closeErr
+}
+
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
 }
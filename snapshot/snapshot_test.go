@@ -0,0 +1,143 @@
+package snapshot
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSnapshot(t *testing.T, store Store, collection, schema, docs string) {
+	t.Helper()
+	w, err := Begin(store, collection, Source{Engine: "test"}, CodecGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SaveSchema([]byte(schema)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SaveDocuments(strings.NewReader(docs)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReaderKeepsItsSnapshot(t *testing.T) {
+	store := NewLocal(t.TempDir())
+	writeSnapshot(t, store, "products", "v1", "{\"id\":1}\n")
+
+	r, err := OpenReader(store, "products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// A new snapshot committed after the reader was opened is not mixed in
+	writeSnapshot(t, store, "products", "v2", "{\"id\":2}\n")
+
+	if schema, err := r.Schema(); err == nil && string(schema) != "v1" {
+		t.Errorf("schema = %q, want v1 or an error", schema)
+	}
+	if r.Manifest().DocumentCount != 1 {
+		t.Errorf("manifest documents = %d, want 1", r.Manifest().DocumentCount)
+	}
+}
+
+func TestReaderVerifiesWhileStreaming(t *testing.T) {
+	root := t.TempDir()
+	store := NewLocal(root)
+	writeSnapshot(t, store, "products", "{}", "{\"id\":1}\n{\"id\":2}\n")
+
+	// Same size, different bytes: only the checksum can tell
+	path := filepath.Join(root, "products", "schema.json")
+	if err := os.WriteFile(path, []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenReader(store, "products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := r.Schema(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Schema error = %v, want ErrCorrupt", err)
+	}
+
+	docs, err := r.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(docs)
+	if err != nil {
+		t.Fatalf("documents: %v", err)
+	}
+	if string(data) != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Errorf("documents = %q", data)
+	}
+	if err := docs.Close(); err != nil {
+		t.Errorf("Close after a verified read = %v", err)
+	}
+
+	if _, err := Verify(store, "products"); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Verify error = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestDocumentsCloseWithoutFullRead(t *testing.T) {
+	store := NewLocal(t.TempDir())
+	writeSnapshot(t, store, "products", "{}", "{\"id\":1}\n{\"id\":2}\n")
+
+	r, err := OpenReader(store, "products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	docs, err := r.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := docs.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := docs.Close(); err == nil {
+		t.Error("Close after a partial read succeeded, want an error")
+	}
+}
+
+func TestReaderDetectsCorruptDocuments(t *testing.T) {
+	root := t.TempDir()
+	store := NewLocal(root)
+	writeSnapshot(t, store, "products", "{}", "{\"id\":1}\n")
+
+	path := filepath.Join(root, "products", "documents.jsonl.gz")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Trailing bytes gzip itself ignores
+	if err := os.WriteFile(path, append(data, 0), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenReader(store, "products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	docs, err := r.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, readErr := io.ReadAll(docs)
+	closeErr := docs.Close()
+	if !errors.Is(readErr, ErrCorrupt) || !errors.Is(closeErr, ErrCorrupt) {
+		t.Errorf("read error = %v, close error = %v, want ErrCorrupt", readErr, closeErr)
+	}
+}
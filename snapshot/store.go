@@ -0,0 +1,40 @@
+package snapshot
+
+import "io"
+
+// Store is where snapshots are kept. A snapshot is a set of named files
+// per collection; names are slash-separated and relative to the snapshot.
+type Store interface {
+	// Create starts a new snapshot of the collection. Nothing changes for
+	// readers until the returned Sink is committed.
+	Create(collection string) (Sink, error)
+	// Open resolves the collection's committed snapshot. Every file read
+	// through it comes from that snapshot, even if a newer one is committed
+	// meanwhile. A missing snapshot yields an error matching fs.ErrNotExist.
+	Open(collection string) (Snapshot, error)
+	Exists(collection string) (bool, error)
+	// List returns every collection that has a committed snapshot.
+	List() ([]string, error)
+	// Recover cleans up after a crash in the middle of writing a snapshot.
+	Recover() error
+}
+
+// Snapshot is one committed snapshot of a collection, open for reading.
+type Snapshot interface {
+	// Open streams a file of the snapshot. A missing file yields an error
+	// matching fs.ErrNotExist, as does any file of a snapshot that has
+	// been replaced and cleaned up since it was opened.
+	Open(name string) (io.ReadCloser, error)
+	Close() error
+}
+
+// Sink receives the files of a snapshot that is being written.
+type Sink interface {
+	// Create returns a writer for a new file. The file is durable once
+	// the writer has been closed without error.
+	Create(name string) (io.WriteCloser, error)
+	// Commit atomically replaces the collection's snapshot with this one.
+	Commit() error
+	// Abort discards everything written to the sink.
+	Abort() error
+}